@@ -6,7 +6,10 @@ import (
 
 	"github.com/drdofx/talk-parmad/internal/api/controller"
 	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/events"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
+	"github.com/drdofx/talk-parmad/internal/api/realtime"
 	"github.com/drdofx/talk-parmad/internal/api/repository"
 	"github.com/drdofx/talk-parmad/internal/api/routes"
 	"github.com/drdofx/talk-parmad/internal/api/services"
@@ -18,8 +21,11 @@ func main() {
 	app := fx.New(
 		lib.Module,
 		database.Module,
+		events.Module,
+		realtime.Module,
 		repository.Module,
 		services.Module,
+		middleware.Module,
 		controller.Module,
 		routes.Module,
 		fx.Invoke(
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/events"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/realtime"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/services"
+	"go.uber.org/fx"
+)
+
+// recount-votes repairs the denormalized vote counters on threads and replies
+func main() {
+	app := fx.New(
+		lib.Module,
+		database.Module,
+		events.Module,
+		realtime.Module,
+		repository.Module,
+		services.Module,
+		fx.Invoke(
+			recountVotes,
+		),
+	)
+
+	if err := app.Start(context.Background()); err != nil {
+		panic(err)
+	}
+
+	if err := app.Stop(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+func recountVotes(service services.ThreadService) error {
+	fmt.Println("Recounting thread and reply votes")
+
+	if err := service.RecountVotes(); err != nil {
+		return err
+	}
+
+	fmt.Println("Vote counters are up to date")
+	return nil
+}
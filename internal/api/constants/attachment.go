@@ -0,0 +1,29 @@
+package constants
+
+// AttachmentPath is where attachments are downloaded from by id, once the
+// read policy of their forum allows it
+const AttachmentPath = API_PATH + "/thread/attachment"
+
+const (
+	// AttachmentMaxSize caps the attachment size a forum may allow
+	AttachmentMaxSize = 20 << 20 // 20 MB
+	// AttachmentsPerPost is how many attachments a thread or reply may have
+	AttachmentsPerPost = 10
+
+	DefaultAttachmentMaxSize = 10 << 20 // 10 MB
+	DefaultAttachmentTypes   = "image/jpeg,image/png,image/gif,application/pdf"
+)
+
+// AttachmentExtensions lists the types a forum may accept as attachments,
+// keyed by their sniffed content type. Types a browser would run, such as
+// HTML or SVG, are left out on purpose since files are served from the
+// API's own origin.
+var AttachmentExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
@@ -0,0 +1,7 @@
+package constants
+
+const (
+	BanAppealPending  = "pending"
+	BanAppealAccepted = "accepted"
+	BanAppealRejected = "rejected"
+)
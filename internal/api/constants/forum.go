@@ -0,0 +1,11 @@
+package constants
+
+const (
+	ForumVisibilityPublic        = "public"
+	ForumVisibilityRequestToJoin = "request_to_join"
+	ForumVisibilityInviteOnly    = "invite_only"
+
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
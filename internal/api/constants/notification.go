@@ -0,0 +1,15 @@
+package constants
+
+const (
+	NotificationThreadReply       = "thread_reply"
+	NotificationReplyReply        = "reply_reply"
+	NotificationThreadVote        = "thread_vote"
+	NotificationReplyVote         = "reply_vote"
+	NotificationRemovedFromForum  = "removed_from_forum"
+	NotificationModeratorPromoted = "moderator_promoted"
+	NotificationModeratorDemoted  = "moderator_demoted"
+	NotificationHeadModerator     = "head_moderator"
+	NotificationMention           = "mention"
+	NotificationBanAppealReviewed = "ban_appeal_reviewed"
+	NotificationJoinRequest       = "join_request_reviewed"
+)
@@ -0,0 +1,6 @@
+package constants
+
+const (
+	SearchHitThread = "thread"
+	SearchHitReply  = "reply"
+)
@@ -0,0 +1,9 @@
+package constants
+
+// Names of the events pushed to streaming clients
+const (
+	StreamThreadCreated       = "thread.created"
+	StreamReplyCreated        = "reply.created"
+	StreamVotesChanged        = "votes.changed"
+	StreamNotificationCreated = "notification.created"
+)
@@ -0,0 +1,12 @@
+package constants
+
+const (
+	RoleAdmin = "Admin"
+	RoleUser  = "User"
+
+	UserStatusActive   = "Active"
+	UserStatusInactive = "Inactive"
+
+	ModeratorRankHead   = "Head"
+	ModeratorRankMember = "Member"
+)
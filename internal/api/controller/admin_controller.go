@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ADMIN ONLY CONTROLLERS
+type AdminController interface {
+	DeactivateUser(c *gin.Context)
+	ReactivateUser(c *gin.Context)
+	ListUsers(c *gin.Context)
+	SetUserRole(c *gin.Context)
+	ListForums(c *gin.Context)
+	RestoreForum(c *gin.Context)
+	HardDeleteForum(c *gin.Context)
+	GetSiteStats(c *gin.Context)
+}
+
+type adminController struct {
+	services    services.AdminService
+	userService services.UserService
+	validate    *validator.Validate
+}
+
+func NewAdminController(service services.AdminService, userService services.UserService, validate *validator.Validate) AdminController {
+	return &adminController{service, userService, validate}
+}
+
+func (ctr *adminController) DeactivateUser(c *gin.Context) {
+	ctr.setUserStatus(c, constants.UserStatusInactive)
+}
+
+func (ctr *adminController) ReactivateUser(c *gin.Context) {
+	ctr.setUserStatus(c, constants.UserStatusActive)
+}
+
+func (ctr *adminController) setUserStatus(c *gin.Context, status string) {
+	var req request.ReqSetUserStatus
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.userService.SetUserStatus(&req, status, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *adminController) ListUsers(c *gin.Context) {
+	var req request.ReqAdminListUser
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	res, pagination, err := ctr.services.ListUsers(&req)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
+func (ctr *adminController) SetUserRole(c *gin.Context) {
+	var req request.ReqAdminSetRole
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.SetUserRole(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *adminController) ListForums(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	res, pagination, err := ctr.services.ListForums(&req)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
+func (ctr *adminController) RestoreForum(c *gin.Context) {
+	var req request.ReqAdminForum
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	err := ctr.services.RestoreForum(&req)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *adminController) HardDeleteForum(c *gin.Context) {
+	var req request.ReqAdminForum
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	err := ctr.services.HardDeleteForum(&req)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *adminController) GetSiteStats(c *gin.Context) {
+	res, err := ctr.services.GetSiteStats()
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
@@ -7,5 +7,10 @@ var Module = fx.Module("controller",
 		NewUserController,
 		NewForumController,
 		NewThreadController,
+		NewAdminController,
+		NewSearchController,
+		NewNotificationController,
+		NewStreamController,
+		NewUploadController,
 	),
 )
@@ -15,6 +15,7 @@ import (
 type ForumController interface {
 	CreateForum(c *gin.Context)
 	JoinForum(c *gin.Context)
+	LeaveForum(c *gin.Context)
 	ListUserForum(c *gin.Context)
 	ListDiscoverForum(c *gin.Context)
 	DetailForum(c *gin.Context)
@@ -22,7 +23,20 @@ type ForumController interface {
 	SearchForum(c *gin.Context)
 	EditForum(c *gin.Context)       // only moderator
 	DeleteForum(c *gin.Context)     // only moderator
+	RestoreForum(c *gin.Context)    // only moderator
 	RemoveFromForum(c *gin.Context) // only moderator
+	ListModerators(c *gin.Context)
+	PromoteModerator(c *gin.Context)      // only head moderator
+	DemoteModerator(c *gin.Context)       // only head moderator
+	TransferHeadModerator(c *gin.Context) // only head moderator
+	ListBans(c *gin.Context)              // only moderator
+	AppealBan(c *gin.Context)             // only banned user
+	ReviewBanAppeal(c *gin.Context)       // only moderator
+	RequestToJoin(c *gin.Context)
+	ListJoinRequests(c *gin.Context)  // only moderator
+	ReviewJoinRequest(c *gin.Context) // only moderator
+	CreateInvite(c *gin.Context)      // only moderator
+	JoinByInvite(c *gin.Context)
 }
 
 type forumController struct {
@@ -90,10 +104,52 @@ func (ctr *forumController) JoinForum(c *gin.Context) {
 	helper.HandleSuccessResponse(c, nil)
 }
 
+func (ctr *forumController) LeaveForum(c *gin.Context) {
+	var req request.ReqLeaveForum
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.LeaveForum(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
 func (ctr *forumController) ListUserForum(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.ListUserForum(&user)
+	res, pagination, err := ctr.services.ListUserForum(&user, &req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -101,13 +157,27 @@ func (ctr *forumController) ListUserForum(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 func (ctr *forumController) ListDiscoverForum(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.DiscoverForum(&user)
+	res, pagination, err := ctr.services.DiscoverForum(&user, &req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -115,7 +185,7 @@ func (ctr *forumController) ListDiscoverForum(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 func (ctr *forumController) DetailForum(c *gin.Context) {
@@ -135,7 +205,7 @@ func (ctr *forumController) DetailForum(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.DetailForum(&user, &req)
+	res, pagination, err := ctr.services.DetailForum(&user, &req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -143,13 +213,27 @@ func (ctr *forumController) DetailForum(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 func (ctr *forumController) ListThreadForumHome(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.ListThreadForumHome(&user)
+	res, pagination, err := ctr.services.ListThreadForumHome(&user, &req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -157,7 +241,7 @@ func (ctr *forumController) ListThreadForumHome(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 func (ctr *forumController) SearchForum(c *gin.Context) {
@@ -175,7 +259,7 @@ func (ctr *forumController) SearchForum(c *gin.Context) {
 		return
 	}
 
-	res, err := ctr.services.SearchForum(&req)
+	res, pagination, err := ctr.services.SearchForum(&req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -185,10 +269,10 @@ func (ctr *forumController) SearchForum(c *gin.Context) {
 
 	if len(*res) == 0 {
 		// give default response of empty slice
-		helper.HandleSuccessResponse(c, []response.ResSearchForum{})
+		helper.HandlePaginatedResponse(c, []response.ResSearchForum{}, pagination)
 		return
 	}
-	helper.HandleSuccessResponse(c, *res)
+	helper.HandlePaginatedResponse(c, *res, pagination)
 }
 
 // MODERATOR ONLY CONTROLLERS
@@ -209,7 +293,7 @@ func (ctr *forumController) EditForum(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	_, err := ctr.services.CheckModeratorForum(&request.ReqCheckModeratorForum{ForumID: req.ForumID, UserID: user.UserID})
+	res, err := ctr.services.EditForum(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -217,7 +301,111 @@ func (ctr *forumController) EditForum(c *gin.Context) {
 		return
 	}
 
-	res, err := ctr.services.EditForum(&req)
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *forumController) DeleteForum(c *gin.Context) {
+	var req request.ReqDeleteForum
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.DeleteForum(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *forumController) RestoreForum(c *gin.Context) {
+	var req request.ReqRestoreForum
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.RestoreForum(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *forumController) RemoveFromForum(c *gin.Context) {
+	var req request.ReqRemoveFromForum
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.RemoveFromForum(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *forumController) ListModerators(c *gin.Context) {
+	var req request.ReqListModerator
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.services.ListModerators(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -228,8 +416,9 @@ func (ctr *forumController) EditForum(c *gin.Context) {
 	helper.HandleSuccessResponse(c, res)
 }
 
-func (ctr *forumController) DeleteForum(c *gin.Context) {
-	var req request.ReqDeleteForum
+// HEAD MODERATOR ONLY CONTROLLERS
+func (ctr *forumController) PromoteModerator(c *gin.Context) {
+	var req request.ReqPromoteModerator
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
@@ -245,7 +434,7 @@ func (ctr *forumController) DeleteForum(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	_, err := ctr.services.CheckModeratorForum(&request.ReqCheckModeratorForum{ForumID: req.ForumID, UserID: user.UserID})
+	res, err := ctr.services.PromoteModerator(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -253,7 +442,27 @@ func (ctr *forumController) DeleteForum(c *gin.Context) {
 		return
 	}
 
-	err = ctr.services.DeleteForum(&req)
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *forumController) DemoteModerator(c *gin.Context) {
+	var req request.ReqDemoteModerator
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.DemoteModerator(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -264,8 +473,64 @@ func (ctr *forumController) DeleteForum(c *gin.Context) {
 	helper.HandleSuccessResponse(c, nil)
 }
 
-func (ctr *forumController) RemoveFromForum(c *gin.Context) {
-	var req request.ReqRemoveFromForum
+func (ctr *forumController) TransferHeadModerator(c *gin.Context) {
+	var req request.ReqTransferHeadModerator
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.TransferHeadModerator(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *forumController) ListBans(c *gin.Context) {
+	var req request.ReqListBan
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.ListBans(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
+func (ctr *forumController) AppealBan(c *gin.Context) {
+	var req request.ReqAppealBan
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
@@ -281,7 +546,7 @@ func (ctr *forumController) RemoveFromForum(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	_, err := ctr.services.CheckModeratorForum(&request.ReqCheckModeratorForum{ForumID: req.ForumID, UserID: user.UserID})
+	res, err := ctr.services.AppealBan(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -289,7 +554,27 @@ func (ctr *forumController) RemoveFromForum(c *gin.Context) {
 		return
 	}
 
-	err = ctr.services.RemoveFromForum(&req)
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *forumController) ReviewBanAppeal(c *gin.Context) {
+	var req request.ReqReviewBanAppeal
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.ReviewBanAppeal(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -299,3 +584,143 @@ func (ctr *forumController) RemoveFromForum(c *gin.Context) {
 
 	helper.HandleSuccessResponse(c, nil)
 }
+
+func (ctr *forumController) RequestToJoin(c *gin.Context) {
+	var req request.ReqRequestToJoin
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.services.RequestToJoin(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *forumController) ListJoinRequests(c *gin.Context) {
+	var req request.ReqListJoinRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.ListJoinRequests(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
+func (ctr *forumController) ReviewJoinRequest(c *gin.Context) {
+	var req request.ReqReviewJoinRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.ReviewJoinRequest(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *forumController) CreateInvite(c *gin.Context) {
+	var req request.ReqCreateInvite
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.services.CreateInvite(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *forumController) JoinByInvite(c *gin.Context) {
+	var req request.ReqJoinByInvite
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.services.JoinByInvite(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type NotificationController interface {
+	ListNotifications(c *gin.Context)
+	MarkRead(c *gin.Context)
+	MarkAllRead(c *gin.Context)
+}
+
+type notificationController struct {
+	services services.NotificationService
+	validate *validator.Validate
+}
+
+func NewNotificationController(service services.NotificationService, validate *validator.Validate) NotificationController {
+	return &notificationController{service, validate}
+}
+
+func (ctr *notificationController) ListNotifications(c *gin.Context) {
+	var req request.ReqListNotification
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.ListNotifications(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
+func (ctr *notificationController) MarkRead(c *gin.Context) {
+	var req request.ReqMarkNotificationRead
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.MarkRead(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *notificationController) MarkAllRead(c *gin.Context) {
+	user := helper.GetUserData(c)
+
+	err := ctr.services.MarkAllRead(&user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
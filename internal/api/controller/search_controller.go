@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type SearchController interface {
+	Search(c *gin.Context)
+}
+
+type searchController struct {
+	services services.SearchService
+	validate *validator.Validate
+}
+
+func NewSearchController(service services.SearchService, validate *validator.Validate) SearchController {
+	return &searchController{service, validate}
+}
+
+func (ctr *searchController) Search(c *gin.Context) {
+	var req request.ReqSearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.Search(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
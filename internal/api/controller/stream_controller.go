@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// streamHeartbeat keeps idle connections open through proxies and is when
+// the session and forum access of a streaming client are checked again
+const streamHeartbeat = 30 * time.Second
+
+type StreamController interface {
+	Stream(c *gin.Context)
+}
+
+type streamController struct {
+	services services.StreamService
+	validate *validator.Validate
+}
+
+func NewStreamController(service services.StreamService, validate *validator.Validate) StreamController {
+	return &streamController{service, validate}
+}
+
+func (ctr *streamController) Stream(c *gin.Context) {
+	var req request.ReqStream
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	sub, err := ctr.services.Subscribe(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer ctr.services.Unsubscribe(sub)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case msg := <-sub.C:
+			c.SSEvent(msg.Event, msg)
+			return true
+		case <-heartbeat.C:
+			// Stop streaming to users who logged out, were deactivated or
+			// lost access to what they follow
+			if err := ctr.services.CheckStream(&req, &user); err != nil {
+				c.SSEvent("close", err.Error())
+				return false
+			}
+
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
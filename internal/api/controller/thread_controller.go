@@ -3,7 +3,6 @@ package controller
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/drdofx/talk-parmad/internal/api/helper"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
@@ -16,15 +15,20 @@ import (
 type ThreadController interface {
 	CreateThread(c *gin.Context)
 	VoteThread(c *gin.Context)
+	RetractVoteThread(c *gin.Context)
 	EditThread(c *gin.Context)
 	DetailThread(c *gin.Context)
+	ListReplyChildren(c *gin.Context)
 	CreateReply(c *gin.Context)
 	VoteReply(c *gin.Context)
+	RetractVoteReply(c *gin.Context)
 	EditReply(c *gin.Context)
 	ListUserThread(c *gin.Context)
 	ListUserReply(c *gin.Context)
-	DeleteThread(c *gin.Context) // only moderator
-	DeleteReply(c *gin.Context)  // only moderator
+	ListMentionedThreads(c *gin.Context)
+	DeleteThread(c *gin.Context)  // only moderator
+	RestoreThread(c *gin.Context) // only moderator
+	DeleteReply(c *gin.Context)   // only moderator
 }
 
 type threadController struct {
@@ -93,6 +97,34 @@ func (ctr *threadController) VoteThread(c *gin.Context) {
 	helper.HandleSuccessResponse(c, res)
 }
 
+func (ctr *threadController) RetractVoteThread(c *gin.Context) {
+	var req request.ReqRetractVoteThread
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.RetractVoteThread(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, nil)
+}
+
 func (ctr *threadController) EditThread(c *gin.Context) {
 	var req request.ReqEditThread
 
@@ -122,9 +154,23 @@ func (ctr *threadController) EditThread(c *gin.Context) {
 }
 
 func (ctr *threadController) ListUserThread(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.ListUserThread(&user)
+	res, pagination, err := ctr.services.ListUserThread(&user, &req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -132,13 +178,27 @@ func (ctr *threadController) ListUserThread(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 func (ctr *threadController) ListUserReply(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.ListUserReply(&user)
+	res, pagination, err := ctr.services.ListUserReply(&user, &req)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -146,7 +206,35 @@ func (ctr *threadController) ListUserReply(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
+func (ctr *threadController) ListMentionedThreads(c *gin.Context) {
+	var req request.ReqPagination
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.ListMentionedThreads(&user, &req)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 func (ctr *threadController) DetailThread(c *gin.Context) {
@@ -164,7 +252,9 @@ func (ctr *threadController) DetailThread(c *gin.Context) {
 		return
 	}
 
-	res, err := ctr.services.DetailThread(&req)
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.DetailThread(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -172,10 +262,38 @@ func (ctr *threadController) DetailThread(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandlePaginatedResponse(c, res, pagination)
 }
 
 // Reply controller
+func (ctr *threadController) ListReplyChildren(c *gin.Context) {
+	var req request.ReqListReplyChildren
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, pagination, err := ctr.services.ListReplyChildren(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandlePaginatedResponse(c, res, pagination)
+}
+
 func (ctr *threadController) CreateReply(c *gin.Context) {
 	var req request.ReqSaveReply
 
@@ -232,8 +350,8 @@ func (ctr *threadController) VoteReply(c *gin.Context) {
 	helper.HandleSuccessResponse(c, res)
 }
 
-func (ctr *threadController) EditReply(c *gin.Context) {
-	var req request.ReqEditReply
+func (ctr *threadController) RetractVoteReply(c *gin.Context) {
+	var req request.ReqRetractVoteReply
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
@@ -249,7 +367,7 @@ func (ctr *threadController) EditReply(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	res, err := ctr.services.EditReply(&req, &user)
+	err := ctr.services.RetractVoteReply(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -257,12 +375,11 @@ func (ctr *threadController) EditReply(c *gin.Context) {
 		return
 	}
 
-	helper.HandleSuccessResponse(c, res)
+	helper.HandleSuccessResponse(c, nil)
 }
 
-// MODERATOR ONLY CONTROLLERS
-func (ctr *threadController) DeleteThread(c *gin.Context) {
-	var req request.ReqDeleteThread
+func (ctr *threadController) EditReply(c *gin.Context) {
+	var req request.ReqEditReply
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
@@ -278,22 +395,36 @@ func (ctr *threadController) DeleteThread(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	threadIdInt, _ := strconv.Atoi(req.ThreadID)
-	thread, err := ctr.services.GetThreadByID(uint(threadIdInt))
+	res, err := ctr.services.EditReply(&req, &user)
+
 	if err != nil {
 		lib.CommonLogger().Error(err)
 		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	_, err = ctr.services.CheckModeratorForumFromThread(thread, &user)
-	if err != nil {
+	helper.HandleSuccessResponse(c, res)
+}
+
+// MODERATOR ONLY CONTROLLERS
+func (ctr *threadController) DeleteThread(c *gin.Context) {
+	var req request.ReqDeleteThread
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
-		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
 		return
 	}
 
-	err = ctr.services.DeleteThread(thread)
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.DeleteThread(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
@@ -304,8 +435,8 @@ func (ctr *threadController) DeleteThread(c *gin.Context) {
 	helper.HandleSuccessResponse(c, nil)
 }
 
-func (ctr *threadController) DeleteReply(c *gin.Context) {
-	var req request.ReqDeleteReply
+func (ctr *threadController) RestoreThread(c *gin.Context) {
+	var req request.ReqRestoreThread
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
@@ -321,22 +452,35 @@ func (ctr *threadController) DeleteReply(c *gin.Context) {
 
 	user := helper.GetUserData(c)
 
-	replyIdInt, _ := strconv.Atoi(req.ReplyID)
-	thread, reply, err := ctr.services.GetThreadAndReplyByReplyID(uint(replyIdInt))
+	err := ctr.services.RestoreThread(&req, &user)
+
 	if err != nil {
 		lib.CommonLogger().Error(err)
 		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	_, err = ctr.services.CheckModeratorForumFromThread(thread, &user)
-	if err != nil {
+	helper.HandleSuccessResponse(c, nil)
+}
+
+func (ctr *threadController) DeleteReply(c *gin.Context) {
+	var req request.ReqDeleteReply
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		lib.CommonLogger().Error(err)
-		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
 		return
 	}
 
-	err = ctr.services.DeleteReply(reply)
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	err := ctr.services.DeleteReply(&req, &user)
 
 	if err != nil {
 		lib.CommonLogger().Error(err)
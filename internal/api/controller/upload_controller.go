@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type UploadController interface {
+	UploadForumImage(c *gin.Context)
+	UploadProfileImage(c *gin.Context)
+	UploadAttachment(c *gin.Context)
+	GetFile(c *gin.Context)
+	GetAttachment(c *gin.Context)
+}
+
+type uploadController struct {
+	service  services.UploadService
+	validate *validator.Validate
+}
+
+func NewUploadController(service services.UploadService, validate *validator.Validate) UploadController {
+	return &uploadController{service, validate}
+}
+
+func (ctr *uploadController) UploadForumImage(c *gin.Context) {
+	var req request.ReqUploadForumImage
+
+	if err := c.ShouldBind(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.service.UploadForumImage(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *uploadController) UploadProfileImage(c *gin.Context) {
+	var req request.ReqUploadProfileImage
+
+	if err := c.ShouldBind(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.service.UploadProfileImage(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *uploadController) UploadAttachment(c *gin.Context) {
+	var req request.ReqUploadAttachment
+
+	if err := c.ShouldBind(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	res, err := ctr.service.UploadAttachment(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helper.HandleSuccessResponse(c, res)
+}
+
+func (ctr *uploadController) GetFile(c *gin.Context) {
+	var req request.ReqGetFile
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	blob, signedURL, err := ctr.service.OpenFile(&req)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	// File names are random and never reused, so they can be cached
+	sendFile(c, blob, signedURL, "public, max-age=31536000, immutable")
+}
+
+func (ctr *uploadController) GetAttachment(c *gin.Context) {
+	var req request.ReqGetAttachment
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	if err := ctr.validate.Struct(&req); err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusBadRequest, "Bad input")
+		return
+	}
+
+	user := helper.GetUserData(c)
+
+	blob, signedURL, err := ctr.service.OpenAttachment(&req, &user)
+
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		helper.HandleErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	// Shared caches must not keep a file only some users may read
+	sendFile(c, blob, signedURL, "private, no-store")
+}
+
+// sendFile redirects to the signed URL when there is one and writes the
+// blob otherwise
+func sendFile(c *gin.Context, blob *lib.Blob, signedURL string, cacheControl string) {
+	if signedURL != "" {
+		c.Redirect(http.StatusFound, signedURL)
+		return
+	}
+
+	defer blob.Body.Close()
+
+	c.DataFromReader(http.StatusOK, blob.Size, blob.ContentType, blob.Body, map[string]string{
+		"Cache-Control":          cacheControl,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
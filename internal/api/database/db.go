@@ -82,6 +82,17 @@ func (d *Database) migrateDB() error {
 		models.Reply{},
 		models.ThreadVote{},
 		models.ReplyVote{},
+		models.Session{},
+		models.RefreshToken{},
+		models.PasswordReset{},
+		models.Notification{},
+		models.Attachment{},
+		models.Mention{},
+		models.ForumBan{},
+		models.BanAppeal{},
+		models.ForumLeave{},
+		models.JoinRequest{},
+		models.ForumInvite{},
 	)
 
 	if err != nil {
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("events",
+	fx.Provide(NewBus),
+)
+
+// Event is something that happened in the app that other parts of it may
+// react to, like sending notifications
+type Event interface {
+	Name() string
+}
+
+type Handler func(event Event)
+
+// Bus delivers published events to the handlers subscribed to their name.
+// Services publish once their transaction is committed, so handlers only
+// ever see changes that were saved.
+type Bus interface {
+	Publish(event Event)
+	Subscribe(name string, handler Handler)
+}
+
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus returns an in-process bus calling the handlers synchronously, in
+// the order they subscribed
+func NewBus() Bus {
+	return &bus{handlers: map[string][]Handler{}}
+}
+
+func (b *bus) Publish(event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Name()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.deliver(handler, event)
+	}
+}
+
+func (b *bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// deliver calls a handler, making sure a failing one does not break the
+// request that published the event or the other handlers
+func (b *bus) deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			lib.CommonLogger().Error(fmt.Sprintf("event handler for %s panicked: %v", event.Name(), r))
+		}
+	}()
+
+	handler(event)
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+)
+
+const (
+	ThreadCreatedEvent            = "thread.created"
+	ReplyCreatedEvent             = "reply.created"
+	ThreadVotedEvent              = "thread.voted"
+	ThreadVoteRetractedEvent      = "thread.vote_retracted"
+	ReplyVotedEvent               = "reply.voted"
+	ReplyVoteRetractedEvent       = "reply.vote_retracted"
+	NotificationCreatedEvent      = "notification.created"
+	MemberRemovedEvent            = "forum.member_removed"
+	ModeratorPromotedEvent        = "forum.moderator_promoted"
+	ModeratorDemotedEvent         = "forum.moderator_demoted"
+	HeadModeratorTransferredEvent = "forum.head_moderator_transferred"
+	UsersMentionedEvent           = "post.users_mentioned"
+	BanAppealReviewedEvent        = "forum.ban_appeal_reviewed"
+	JoinRequestReviewedEvent      = "forum.join_request_reviewed"
+)
+
+type ThreadCreated struct {
+	Actor  lib.UserData
+	Thread models.Thread
+}
+
+func (e ThreadCreated) Name() string { return ThreadCreatedEvent }
+
+type ReplyCreated struct {
+	Actor  lib.UserData
+	Thread models.Thread
+	Reply  models.Reply
+}
+
+func (e ReplyCreated) Name() string { return ReplyCreatedEvent }
+
+type ThreadVoted struct {
+	Actor  lib.UserData
+	Thread models.Thread
+	Vote   bool
+}
+
+func (e ThreadVoted) Name() string { return ThreadVotedEvent }
+
+type ThreadVoteRetracted struct {
+	Actor  lib.UserData
+	Thread models.Thread
+}
+
+func (e ThreadVoteRetracted) Name() string { return ThreadVoteRetractedEvent }
+
+type ReplyVoted struct {
+	Actor  lib.UserData
+	Thread models.Thread
+	Reply  models.Reply
+	Vote   bool
+}
+
+func (e ReplyVoted) Name() string { return ReplyVotedEvent }
+
+type ReplyVoteRetracted struct {
+	Actor  lib.UserData
+	Thread models.Thread
+	Reply  models.Reply
+}
+
+func (e ReplyVoteRetracted) Name() string { return ReplyVoteRetractedEvent }
+
+// NotificationCreated is published once a notification has been saved
+type NotificationCreated struct {
+	Notification models.Notification
+}
+
+func (e NotificationCreated) Name() string { return NotificationCreatedEvent }
+
+// MemberRemoved is published when a moderator removes UserID from a forum
+type MemberRemoved struct {
+	Actor   lib.UserData
+	ForumID uint
+	UserID  uint
+}
+
+func (e MemberRemoved) Name() string { return MemberRemovedEvent }
+
+type ModeratorPromoted struct {
+	Actor   lib.UserData
+	ForumID uint
+	UserID  uint
+}
+
+func (e ModeratorPromoted) Name() string { return ModeratorPromotedEvent }
+
+type ModeratorDemoted struct {
+	Actor   lib.UserData
+	ForumID uint
+	UserID  uint
+}
+
+func (e ModeratorDemoted) Name() string { return ModeratorDemotedEvent }
+
+// HeadModeratorTransferred is published when the head of a forum hands the
+// role over to UserID
+type HeadModeratorTransferred struct {
+	Actor   lib.UserData
+	ForumID uint
+	UserID  uint
+}
+
+func (e HeadModeratorTransferred) Name() string { return HeadModeratorTransferredEvent }
+
+// UsersMentioned is published when a thread, or Reply when it is set, starts
+// mentioning the forum members in UserIDs
+type UsersMentioned struct {
+	Actor   lib.UserData
+	Thread  models.Thread
+	Reply   *models.Reply
+	UserIDs []uint
+}
+
+func (e UsersMentioned) Name() string { return UsersMentionedEvent }
+
+// BanAppealReviewed is published when a moderator accepts or rejects the
+// appeal UserID filed against their ban from a forum
+type BanAppealReviewed struct {
+	Actor   lib.UserData
+	ForumID uint
+	UserID  uint
+	Status  string
+}
+
+func (e BanAppealReviewed) Name() string { return BanAppealReviewedEvent }
+
+// JoinRequestReviewed is published when a moderator approves or rejects
+// the request of UserID to join a forum
+type JoinRequestReviewed struct {
+	Actor   lib.UserData
+	ForumID uint
+	UserID  uint
+	Status  string
+}
+
+func (e JoinRequestReviewed) Name() string { return JoinRequestReviewedEvent }
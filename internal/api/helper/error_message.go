@@ -1,14 +1,57 @@
 package helper
 
 const (
-	UserExists           = "user already exists"
-	FailedLogin          = "failed to login because of wrong email or password"
-	FailedGenerateToken  = "failed to generate token"
-	RoleNotAuthorized    = "role not authorized for this action"
-	ForumExists          = "forum name already exists"
-	UserAlreadyMember    = "user is already a member of the forum"
-	UserNotModerator     = "user is not a moderator of the forum"
-	UserNotMember        = "user is not a member of the forum"
-	UserNotCreatedThread = "user did not create the thread"
-	UserNotCreatedReply  = "user did not create the reply"
+	UserExists             = "user already exists"
+	FailedLogin            = "failed to login because of wrong email or password"
+	WrongOldPassword       = "old password is incorrect"
+	InvalidResetToken      = "reset token is invalid or expired"
+	FailedGenerateToken    = "failed to generate token"
+	UserInactive           = "user account is inactive"
+	CannotChangeSelf       = "admin cannot change their own account"
+	InvalidRefreshToken    = "refresh token is invalid or expired"
+	SessionRevoked         = "session has been revoked"
+	RoleNotAuthorized      = "role not authorized for this action"
+	ForumExists            = "forum name already exists"
+	ForumNotDeleted        = "forum is not deleted"
+	ThreadNotDeleted       = "thread is not deleted"
+	UserAlreadyMember      = "user is already a member of the forum"
+	UserNotModerator       = "user is not a moderator of the forum"
+	UserNotHeadModerator   = "user is not the head moderator of the forum"
+	RankNotAuthorized      = "moderator rank not authorized for this action"
+	CannotRemoveHead       = "head moderator cannot be removed from the forum"
+	TargetRankNotLower     = "moderator cannot act on a moderator of equal or higher rank"
+	UserAlreadyModerator   = "user is already a moderator of the forum"
+	CannotDemoteHead       = "head moderator must transfer the head role first"
+	CannotLeaveAsHead      = "head moderator must transfer the head role before leaving"
+	CannotTargetSelf       = "user cannot perform this action on themselves"
+	UserNotMember          = "user is not a member of the forum"
+	UserNotCreatedThread   = "user did not create the thread"
+	UserNotCreatedReply    = "user did not create the reply"
+	VoteNotFound           = "user has not voted"
+	InvalidCursor          = "pagination cursor is invalid"
+	SortNotSupported       = "sort option is not supported for this list"
+	ParentReplyNotInThread = "parent reply does not belong to the thread"
+	ForumNotFound          = "forum not found"
+	ThreadNotFound         = "thread not found"
+	FileTooLarge           = "file is too large"
+	FileTypeNotAllowed     = "file type is not allowed"
+	InvalidImage           = "image could not be decoded"
+	FileNotFound           = "file not found"
+	AttachmentNotAvailable = "attachment does not exist or is already used"
+	AttachmentTypeInvalid  = "attachment type is not supported"
+	UserBanned             = "user is banned from the forum"
+	UserNotBanned          = "user is not banned from the forum"
+	BanExpiryInPast        = "ban expiry must be in the future"
+	AppealNotFound         = "ban appeal not found"
+	AppealAlreadyPending   = "ban appeal is already pending"
+	AppealAlreadyReviewed  = "ban appeal has already been reviewed"
+	ForumMembersOnly       = "forum content is only visible to members"
+	JoinRequestRequired    = "forum requires a join request"
+	InviteRequired         = "forum can only be joined with an invite"
+	ForumNotRequestToJoin  = "forum does not take join requests"
+	JoinAlreadyRequested   = "user already requested to join the forum"
+	JoinRequestNotFound    = "join request not found"
+	JoinRequestNotPending  = "join request has already been reviewed"
+	InviteInvalid          = "invite is invalid or expired"
+	InviteExpiryInPast     = "invite expiry must be in the future"
 )
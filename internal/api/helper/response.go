@@ -3,13 +3,15 @@ package helper
 import (
 	"net/http"
 
+	"github.com/drdofx/talk-parmad/internal/api/response"
 	"github.com/gin-gonic/gin"
 )
 
 type Response struct {
-	Status  int         `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Status     int                     `json:"status"`
+	Message    string                  `json:"message"`
+	Data       interface{}             `json:"data"`
+	Pagination *response.ResPagination `json:"pagination,omitempty"`
 }
 
 func HandleSuccessResponse(c *gin.Context, data interface{}) {
@@ -21,6 +23,16 @@ func HandleSuccessResponse(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, res)
 }
 
+func HandlePaginatedResponse(c *gin.Context, data interface{}, pagination *response.ResPagination) {
+	res := Response{
+		Status:     http.StatusOK,
+		Message:    "Success",
+		Data:       data,
+		Pagination: pagination,
+	}
+	c.JSON(http.StatusOK, res)
+}
+
 func HandleErrorResponse(c *gin.Context, status int, message string) {
 	res := Response{
 		Status:  status,
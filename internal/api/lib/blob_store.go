@@ -0,0 +1,99 @@
+package lib
+
+import (
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Blob is a stored file opened for reading
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// BlobStore keeps uploaded files under slash separated keys
+type BlobStore interface {
+	Put(key string, contentType string, data []byte) error
+	Get(key string) (*Blob, error)
+	Delete(key string) error
+	// SignedURL returns a short-lived URL the blob can be downloaded from
+	// directly, or an empty string when it can only be read through Get
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// NewBlobStore returns the store selected by BLOB_DRIVER. Anything other
+// than "s3" keeps the files on the local filesystem.
+func NewBlobStore(env *Env) (BlobStore, error) {
+	if env.BlobDriver == "s3" {
+		return NewS3BlobStore(env.S3Endpoint, env.S3Region, env.S3Bucket, env.S3AccessKey, env.S3SecretKey)
+	}
+
+	dir := env.BlobLocalDir
+	if dir == "" {
+		dir = "./storage"
+	}
+
+	return NewLocalBlobStore(dir), nil
+}
+
+type localBlobStore struct {
+	root string
+}
+
+func NewLocalBlobStore(root string) BlobStore {
+	return &localBlobStore{root}
+}
+
+// path maps a key inside the root directory, so keys containing ".." can
+// never reach files outside of it
+func (s *localBlobStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (s *localBlobStore) Put(key string, contentType string, data []byte) error {
+	p := s.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, data, 0644)
+}
+
+func (s *localBlobStore) Get(key string) (*Blob, error) {
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &Blob{Body: f, ContentType: contentType, Size: info.Size()}, nil
+}
+
+func (s *localBlobStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *localBlobStore) SignedURL(key string, expires time.Duration) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLCache is a small in-memory cache whose entries expire after a fixed
+// duration
+type TTLCache[K comparable, V any] struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[K]ttlCacheEntry[V]
+}
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:     ttl,
+		entries: make(map[K]ttlCacheEntry[V]),
+	}
+}
+
+// Get returns the cached value and whether it was found and still fresh
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// drop expired entries now and then so the map does not grow forever
+	if len(c.entries) > 10000 {
+		now := time.Now()
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	c.entries[key] = ttlCacheEntry[V]{value, time.Now().Add(c.ttl)}
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
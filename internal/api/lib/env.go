@@ -12,6 +12,24 @@ type Env struct {
 	DBPort     string `mapstructure:"DB_PORT"`
 	DBName     string `mapstructure:"DB_NAME"`
 	Port       string `mapstructure:"PORT"`
+
+	MailDriver       string `mapstructure:"MAIL_DRIVER"`
+	MailFrom         string `mapstructure:"MAIL_FROM"`
+	SMTPHost         string `mapstructure:"SMTP_HOST"`
+	SMTPPort         string `mapstructure:"SMTP_PORT"`
+	SMTPUsername     string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword     string `mapstructure:"SMTP_PASSWORD"`
+	PasswordResetURL string `mapstructure:"PASSWORD_RESET_URL"`
+
+	SearchDriver string `mapstructure:"SEARCH_DRIVER"`
+
+	BlobDriver   string `mapstructure:"BLOB_DRIVER"`
+	BlobLocalDir string `mapstructure:"BLOB_LOCAL_DIR"`
+	S3Endpoint   string `mapstructure:"S3_ENDPOINT"`
+	S3Region     string `mapstructure:"S3_REGION"`
+	S3Bucket     string `mapstructure:"S3_BUCKET"`
+	S3AccessKey  string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey  string `mapstructure:"S3_SECRET_KEY"`
 }
 
 // NewEnv returns a new Env struct
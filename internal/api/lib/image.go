@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxImagePixels bounds the size of decoded images, so a small file
+	// cannot expand to gigabytes of memory
+	MaxImagePixels   = 4096 * 4096
+	thumbnailQuality = 85
+)
+
+// ImageExtensions lists the image types accepted for upload, keyed by
+// their sniffed content type
+var ImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// DetectImageType sniffs the content type of data and reports whether it
+// is an accepted image type
+func DetectImageType(data []byte) (string, bool) {
+	contentType := http.DetectContentType(data)
+	_, ok := ImageExtensions[contentType]
+
+	return contentType, ok
+}
+
+// DecodeImage decodes data after checking its dimensions
+func DecodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Thumbnail scales img down to fit in a size x size square, keeping its
+// aspect ratio. Each target pixel is the average of the source pixels it
+// covers. Images that already fit are only copied.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, maxInt(1, h*size/w)
+		} else {
+			tw, th = maxInt(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, tw, th))
+
+	for y := 0; y < th; y++ {
+		y0 := bounds.Min.Y + y*h/th
+		y1 := maxInt(y0+1, bounds.Min.Y+(y+1)*h/th)
+
+		for x := 0; x < tw; x++ {
+			x0 := bounds.Min.X + x*w/tw
+			x1 := maxInt(x0+1, bounds.Min.X+(x+1)*w/tw)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	return dst
+}
+
+// EncodeThumbnail encodes a thumbnail as PNG for PNG and GIF sources, which
+// may be transparent, and as JPEG otherwise. It returns the content type.
+func EncodeThumbnail(img image.Image, sourceType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if sourceType == "image/jpeg" {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbnailQuality})
+		return buf.Bytes(), "image/jpeg", err
+	}
+
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", err
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
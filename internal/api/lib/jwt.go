@@ -3,6 +3,7 @@ package lib
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -16,24 +17,27 @@ type JWT struct {
 }
 
 type UserData struct {
-	UserID uint
-	Name   string
-	NIM    string
-	Role   string
+	UserID    uint
+	Name      string
+	NIM       string
+	Role      string
+	SessionID uint
 }
 
-func GenerateJWT(user *models.User) string {
+func GenerateJWT(user *models.User, sessionID uint) string {
 	claims := JWT{
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Second * time.Duration(viper.GetInt("DURATION_TOKEN_JWT"))).Unix(),
 			IssuedAt:  time.Now().Unix(),
 			Subject:   strconv.Itoa(int(user.ID)),
+			Id:        strconv.Itoa(int(sessionID)),
 		},
 		Data: UserData{
-			UserID: user.ID,
-			Name:   user.Name,
-			NIM:    *user.NIM,
-			Role:   user.Role,
+			UserID:    user.ID,
+			Name:      user.Name,
+			NIM:       *user.NIM,
+			Role:      user.Role,
+			SessionID: sessionID,
 		},
 	}
 
@@ -48,10 +52,19 @@ func GenerateJWT(user *models.User) string {
 
 }
 
+// RefreshTokenExpiry returns the expiry time of a refresh token issued now
+func RefreshTokenExpiry() time.Time {
+	return time.Now().Add(time.Second * time.Duration(viper.GetInt("DURATION_REFRESH_TOKEN")))
+}
+
+// PasswordResetExpiry returns the expiry time of a password reset token
+// issued now
+func PasswordResetExpiry() time.Time {
+	return time.Now().Add(time.Second * time.Duration(viper.GetInt("DURATION_RESET_TOKEN")))
+}
+
 func ValidateJWT(token string) (*jwt.Token, error) {
-	if token[:7] == "Bearer " {
-		token = token[7:]
-	}
+	token = strings.TrimPrefix(token, "Bearer ")
 
 	return jwt.ParseWithClaims(token, &JWT{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
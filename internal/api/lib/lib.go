@@ -7,5 +7,7 @@ var Module = fx.Module("lib",
 		NewEnv,
 		NewValidator,
 		NewRequestHandler,
+		NewMailer,
+		NewBlobStore,
 	),
 )
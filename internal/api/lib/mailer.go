@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail is a plain text email message
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails to users
+type Mailer interface {
+	Send(mail *Mail) error
+}
+
+// NewMailer returns the mailer selected by MAIL_DRIVER. Anything other than
+// "smtp" writes the mails to a log file, which is meant for local
+// development and tests.
+func NewMailer(env *Env) Mailer {
+	if env.MailDriver == "smtp" {
+		return NewSMTPMailer(env.SMTPHost, env.SMTPPort, env.SMTPUsername, env.SMTPPassword, env.MailFrom)
+	}
+
+	return NewFileMailer("./logs/mail.log")
+}
+
+type smtpMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) Mailer {
+	return &smtpMailer{host, port, username, password, from}
+}
+
+func (m *smtpMailer) Send(mail *Mail) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + mail.To,
+		"Subject: " + mail.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		mail.Body,
+	}, "\r\n")
+
+	return smtp.SendMail(m.host+":"+m.port, auth, m.from, []string{mail.To}, []byte(msg))
+}
+
+type fileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(path string) Mailer {
+	return &fileMailer{path: path}
+}
+
+func (m *fileMailer) Send(mail *Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "--- %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), mail.To, mail.Subject, mail.Body)
+	return err
+}
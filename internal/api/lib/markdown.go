@@ -0,0 +1,673 @@
+package lib
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxMarkdownNesting bounds how deep quotes, lists and inline markup may
+// nest, so hostile input cannot recurse without limit
+const maxMarkdownNesting = 10
+
+// maxAutolinkLength is the longest bare URL that is turned into a link
+const maxAutolinkLength = 2048
+
+// RenderMarkdown renders post text written in Markdown to HTML. It supports
+// paragraphs, headings, quotes, lists, fenced code blocks, code spans,
+// emphasis, links and @mentions. HTML in the source is escaped rather than
+// passed through and links are only kept for safe schemes, so the output is
+// safe to embed as is.
+func RenderMarkdown(src string) string {
+	r := &markdownRenderer{}
+
+	var b strings.Builder
+	r.blocks(&b, markdownLines(src), 0, false)
+
+	return b.String()
+}
+
+// ExtractMentions returns the distinct @handles of a post, in the order
+// they appear. Handles inside code are not mentions.
+func ExtractMentions(src string) []string {
+	r := &markdownRenderer{}
+
+	var b strings.Builder
+	r.blocks(&b, markdownLines(src), 0, false)
+
+	seen := map[string]bool{}
+	var handles []string
+
+	for _, handle := range r.mentions {
+		if !seen[handle] {
+			seen[handle] = true
+			handles = append(handles, handle)
+		}
+	}
+
+	return handles
+}
+
+type markdownRenderer struct {
+	mentions []string
+}
+
+func markdownLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+
+	return strings.Split(src, "\n")
+}
+
+// blocks renders lines as block elements. Paragraphs of tight list items
+// are written without <p> tags.
+func (r *markdownRenderer) blocks(b *strings.Builder, lines []string, depth int, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		indented := len(line)-len(trimmed) >= 4
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case !indented && isCodeFence(trimmed):
+			i = r.codeBlock(b, lines, i)
+		case !indented && headingLevel(trimmed) > 0:
+			level := headingLevel(trimmed)
+			text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(trimmed[level:]), "#"))
+			tag := "h" + strconv.Itoa(level)
+
+			b.WriteString("<" + tag + ">")
+			r.inline(b, text, 0, false)
+			b.WriteString("</" + tag + ">\n")
+			i++
+		case !indented && isThematicBreak(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+		case !indented && strings.HasPrefix(trimmed, ">") && depth < maxMarkdownNesting:
+			i = r.blockquote(b, lines, i, depth)
+		case !indented && isListItem(trimmed) && depth < maxMarkdownNesting:
+			i = r.list(b, lines, i, depth)
+		default:
+			i = r.paragraph(b, lines, i, tight)
+		}
+	}
+}
+
+func (r *markdownRenderer) paragraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || (len(text) > 0 && startsBlock(strings.TrimLeft(lines[i], " "))) {
+			break
+		}
+
+		text = append(text, trimmed)
+	}
+
+	if !tight {
+		b.WriteString("<p>")
+	}
+
+	r.inline(b, strings.Join(text, "\n"), 0, false)
+
+	if !tight {
+		b.WriteString("</p>")
+	}
+	b.WriteString("\n")
+
+	return i
+}
+
+func (r *markdownRenderer) codeBlock(b *strings.Builder, lines []string, i int) int {
+	opening := strings.TrimLeft(lines[i], " ")
+	indent := len(lines[i]) - len(opening)
+	fence := opening[:fenceLength(opening)]
+
+	language := strings.Fields(opening[len(fence):])
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if len(lines[i])-len(trimmed) < 4 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" ") == "" {
+			i++
+			break
+		}
+
+		// Remove the indentation of the opening fence from the content
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if len(language) > 0 && isCodeLanguage(language[0]) {
+		b.WriteString(` class="language-` + language[0] + `"`)
+	}
+	b.WriteString(">")
+
+	if len(code) > 0 {
+		b.WriteString(html.EscapeString(strings.Join(code, "\n")) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+
+	return i
+}
+
+func (r *markdownRenderer) blockquote(b *strings.Builder, lines []string, i int, depth int) int {
+	var quoted []string
+
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+
+		trimmed = strings.TrimPrefix(trimmed[1:], " ")
+		quoted = append(quoted, trimmed)
+	}
+
+	b.WriteString("<blockquote>\n")
+	r.blocks(b, quoted, depth+1, false)
+	b.WriteString("</blockquote>\n")
+
+	return i
+}
+
+func (r *markdownRenderer) list(b *strings.Builder, lines []string, i int, depth int) int {
+	first := strings.TrimLeft(lines[i], " ")
+	ordered, start, _ := listMarker(first)
+
+	var items [][]string
+	contentIndent := 0
+	tight := true
+
+	for i < len(lines) {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		// A new item of the same list
+		if itemOrdered, _, offset := listMarker(trimmed); offset > 0 && itemOrdered == ordered && (len(items) == 0 || indent < contentIndent) && !isThematicBreak(trimmed) {
+			items = append(items, []string{trimmed[offset:]})
+			contentIndent = indent + offset
+			i++
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			// Blank lines only belong to the list when it goes on after them
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+
+			if next == len(lines) {
+				break
+			}
+
+			nextTrimmed := strings.TrimLeft(lines[next], " ")
+			nextOrdered, _, offset := listMarker(nextTrimmed)
+			continues := len(lines[next])-len(nextTrimmed) >= contentIndent || (offset > 0 && nextOrdered == ordered)
+			if !continues {
+				break
+			}
+
+			tight = false
+			items[len(items)-1] = append(items[len(items)-1], "")
+			i++
+			continue
+		}
+
+		item := items[len(items)-1]
+		switch {
+		case indent >= contentIndent:
+			items[len(items)-1] = append(item, line[contentIndent:])
+		case item[len(item)-1] != "" && !startsBlock(trimmed):
+			// Lazy continuation of the item's last paragraph
+			items[len(items)-1] = append(item, trimmed)
+		default:
+			return r.writeList(b, items, ordered, start, depth, tight, i)
+		}
+		i++
+	}
+
+	return r.writeList(b, items, ordered, start, depth, tight, i)
+}
+
+func (r *markdownRenderer) writeList(b *strings.Builder, items [][]string, ordered bool, start int, depth int, tight bool, i int) int {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+
+	b.WriteString("<" + tag)
+	if ordered && start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(start) + `"`)
+	}
+	b.WriteString(">\n")
+
+	for _, item := range items {
+		b.WriteString("<li>")
+
+		var content strings.Builder
+		r.blocks(&content, item, depth+1, tight)
+		b.WriteString(strings.TrimSuffix(content.String(), "\n"))
+
+		b.WriteString("</li>\n")
+	}
+
+	b.WriteString("</" + tag + ">\n")
+
+	return i
+}
+
+// inline renders the inline markup of text. Links can't be nested, so
+// inLink turns them off inside link text.
+func (r *markdownRenderer) inline(b *strings.Builder, text string, depth int, inLink bool) {
+	s := &inlineScan{text: text}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && isASCIIPunct(text[i+1]):
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '\n':
+			b.WriteString("<br>\n")
+			i++
+		case c == '`':
+			i = r.codeSpan(b, s, i)
+		case c == '[' && !inLink && depth < maxMarkdownNesting:
+			i = r.link(b, s, i, depth)
+		case c == '*' || c == '_' || c == '~':
+			i = r.emphasis(b, s, i, depth, inLink)
+		case c == '@':
+			i = r.mention(b, text, i)
+		case c == 'h' && !inLink:
+			i = r.autolink(b, text, i)
+		default:
+			// Write everything up to the next character with a meaning
+			end := i + 1
+			for end < len(text) && !strings.ContainsRune("\\\n`[*_~@h", rune(text[end])) {
+				end++
+			}
+
+			b.WriteString(html.EscapeString(text[i:end]))
+			i = end
+		}
+	}
+}
+
+// inlineScan is the text of one inline call along with what is known about
+// its closing delimiters. Each of them is worked out in a single pass over
+// the text, so openers without a match don't each rescan the rest of it.
+type inlineScan struct {
+	text string
+	// closers holds the position of the bracket closing each [ and (, or -1
+	closers []int
+	// lastCodeRuns maps a backtick run length to the start of the last run
+	// of that length
+	lastCodeRuns map[int]int
+	// unclosed maps an emphasis run to the position after which it has no
+	// closing run
+	unclosed map[emphasisRun]int
+}
+
+type emphasisRun struct {
+	c byte
+	n int
+}
+
+// closer returns the position of the bracket closing the one at i, or -1
+func (s *inlineScan) closer(i int) int {
+	if s.closers == nil {
+		s.closers = make([]int, len(s.text))
+		for j := range s.closers {
+			s.closers[j] = -1
+		}
+
+		// Escaped brackets don't count for link text, but do for the
+		// destination
+		var brackets, parens []int
+		for j := 0; j < len(s.text); j++ {
+			switch s.text[j] {
+			case '\\':
+				j++
+			case '[':
+				brackets = append(brackets, j)
+			case ']':
+				if len(brackets) > 0 {
+					s.closers[brackets[len(brackets)-1]] = j
+					brackets = brackets[:len(brackets)-1]
+				}
+			}
+		}
+
+		for j := 0; j < len(s.text); j++ {
+			switch s.text[j] {
+			case '(':
+				parens = append(parens, j)
+			case ')':
+				if len(parens) > 0 {
+					s.closers[parens[len(parens)-1]] = j
+					parens = parens[:len(parens)-1]
+				}
+			}
+		}
+	}
+
+	return s.closers[i]
+}
+
+// hasCodeRunAfter reports whether a backtick run of length n starts after i
+func (s *inlineScan) hasCodeRunAfter(i int, n int) bool {
+	if s.lastCodeRuns == nil {
+		s.lastCodeRuns = map[int]int{}
+		for j := 0; j < len(s.text); {
+			if s.text[j] != '`' {
+				j++
+				continue
+			}
+
+			m := runLength(s.text, j, '`')
+			s.lastCodeRuns[m] = j
+			j += m
+		}
+	}
+
+	last, ok := s.lastCodeRuns[n]
+	return ok && last > i
+}
+
+func (r *markdownRenderer) codeSpan(b *strings.Builder, s *inlineScan, i int) int {
+	text := s.text
+	n := runLength(text, i, '`')
+
+	if !s.hasCodeRunAfter(i, n) {
+		// No closing run, the backticks are plain text
+		b.WriteString(text[i : i+n])
+		return i + n
+	}
+
+	j := i + n
+	for text[j] != '`' || runLength(text, j, '`') != n {
+		j += runLength(text, j, text[j])
+	}
+
+	code := strings.ReplaceAll(text[i+n:j], "\n", " ")
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+		code = code[1 : len(code)-1]
+	}
+
+	b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+	return j + n
+}
+
+func (r *markdownRenderer) link(b *strings.Builder, s *inlineScan, i int, depth int) int {
+	text := s.text
+
+	// The link text is followed by a destination in parentheses, which ends
+	// at the first unbalanced parenthesis
+	close := s.closer(i)
+	if close < 0 || close+1 >= len(text) || text[close+1] != '(' {
+		b.WriteString("[")
+		return i + 1
+	}
+
+	end := s.closer(close + 1)
+	if end < 0 {
+		b.WriteString("[")
+		return i + 1
+	}
+
+	label := text[i+1 : close]
+	href := strings.TrimSpace(text[close+2 : end])
+
+	if isSafeURL(href) {
+		b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow ugc noopener">`)
+		r.inline(b, label, depth+1, true)
+		b.WriteString("</a>")
+	} else {
+		// Keep the text of links to unsafe URLs such as javascript:
+		r.inline(b, label, depth+1, true)
+	}
+
+	return end + 1
+}
+
+func (r *markdownRenderer) emphasis(b *strings.Builder, s *inlineScan, i int, depth int, inLink bool) int {
+	text := s.text
+	c := text[i]
+	n := runLength(text, i, c)
+
+	// ~~ is strikethrough, a single ~ means nothing
+	valid := n <= 3 && depth < maxMarkdownNesting
+	if c == '~' {
+		valid = n == 2 && depth < maxMarkdownNesting
+	}
+
+	// A run that found no closing run before can't find one later on
+	run := emphasisRun{c, n}
+	if from, ok := s.unclosed[run]; ok && i >= from {
+		valid = false
+	}
+
+	// The opening run must touch the text it emphasizes. An underscore
+	// inside a word, as in snake_case, is not emphasis.
+	if !valid || i+n >= len(text) || text[i+n] == ' ' || text[i+n] == '\n' || (c == '_' && i > 0 && isWordByte(text[i-1])) {
+		b.WriteString(text[i : i+n])
+		return i + n
+	}
+
+	for j := i + n; j < len(text); {
+		if text[j] != c {
+			j++
+			continue
+		}
+
+		m := runLength(text, j, c)
+		closes := m == n && text[j-1] != ' ' && text[j-1] != '\n' && !(c == '_' && j+m < len(text) && isWordByte(text[j+m]))
+
+		if closes {
+			open, end := "<em>", "</em>"
+			switch {
+			case c == '~':
+				open, end = "<del>", "</del>"
+			case n == 2:
+				open, end = "<strong>", "</strong>"
+			case n == 3:
+				open, end = "<strong><em>", "</em></strong>"
+			}
+
+			b.WriteString(open)
+			r.inline(b, text[i+n:j], depth+1, inLink)
+			b.WriteString(end)
+			return j + m
+		}
+		j += m
+	}
+
+	if s.unclosed == nil {
+		s.unclosed = map[emphasisRun]int{}
+	}
+	s.unclosed[run] = i
+
+	b.WriteString(text[i : i+n])
+	return i + n
+}
+
+// mention renders @handle, unless the @ is part of something else such as
+// an email address
+func (r *markdownRenderer) mention(b *strings.Builder, text string, i int) int {
+	if i > 0 && (isWordByte(text[i-1]) || text[i-1] == '@' || text[i-1] == '.') {
+		b.WriteString("@")
+		return i + 1
+	}
+
+	end := i + 1
+	for end < len(text) && (isWordByte(text[end]) || text[end] == '.') {
+		end++
+	}
+
+	handle := strings.TrimRight(text[i+1:end], ".")
+	if handle == "" {
+		b.WriteString("@")
+		return i + 1
+	}
+
+	r.mentions = append(r.mentions, handle)
+
+	b.WriteString(`<span class="mention" data-mention="` + html.EscapeString(handle) + `">@` + html.EscapeString(handle) + `</span>`)
+	return i + 1 + len(handle)
+}
+
+// autolink turns bare http(s) URLs into links
+func (r *markdownRenderer) autolink(b *strings.Builder, text string, i int) int {
+	rest := text[i:]
+	if (i > 0 && isWordByte(text[i-1])) || !(strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) {
+		b.WriteString("h")
+		return i + 1
+	}
+
+	// Only look so far for the end of the URL, as the search starts over
+	// from every h of a URL that isn't linked
+	candidate := rest
+	if len(candidate) > maxAutolinkLength {
+		candidate = candidate[:maxAutolinkLength+1]
+	}
+
+	end := strings.IndexAny(candidate, " \n<>\"")
+	if end < 0 {
+		end = len(candidate)
+	}
+
+	href := strings.TrimRight(candidate[:end], ".,:;!?')")
+	if len(href) > maxAutolinkLength || !strings.Contains(href, "://") || strings.HasSuffix(href, "://") || !isSafeURL(href) {
+		b.WriteString("h")
+		return i + 1
+	}
+
+	b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow ugc noopener">` + html.EscapeString(href) + "</a>")
+	return i + len(href)
+}
+
+func isSafeURL(href string) bool {
+	// Browsers read a backslash as a slash and drop tabs and newlines from
+	// URLs, either of which could turn /\host into a link to another site
+	if href == "" || strings.ContainsAny(href, " \\") || strings.IndexFunc(href, unicode.IsControl) >= 0 {
+		return false
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	case "":
+		// Relative links within the site. //host is a link to another site
+		// without a scheme.
+		return (href[0] == '/' && (len(href) == 1 || href[1] != '/')) || href[0] == '#'
+	}
+
+	return false
+}
+
+// startsBlock reports whether a line interrupts a paragraph
+func startsBlock(trimmed string) bool {
+	return isCodeFence(trimmed) || headingLevel(trimmed) > 0 || isThematicBreak(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || isListItem(trimmed)
+}
+
+func isCodeFence(trimmed string) bool {
+	n := fenceLength(trimmed)
+	return n >= 3 && !(trimmed[0] == '`' && strings.Contains(trimmed[n:], "`"))
+}
+
+func fenceLength(trimmed string) int {
+	if trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return 0
+	}
+
+	return runLength(trimmed, 0, trimmed[0])
+}
+
+func headingLevel(trimmed string) int {
+	n := runLength(trimmed, 0, '#')
+	if n == 0 || n > 6 || (n < len(trimmed) && trimmed[n] != ' ') {
+		return 0
+	}
+
+	return n
+}
+
+func isThematicBreak(trimmed string) bool {
+	if trimmed == "" || !strings.ContainsRune("-*_", rune(trimmed[0])) {
+		return false
+	}
+
+	stripped := strings.ReplaceAll(trimmed, " ", "")
+	return len(stripped) >= 3 && strings.Trim(stripped, stripped[:1]) == ""
+}
+
+func isListItem(trimmed string) bool {
+	_, _, offset := listMarker(trimmed)
+	return offset > 0
+}
+
+// listMarker parses the marker of a list item. offset is where the item's
+// content starts, or 0 when the line isn't a list item.
+func listMarker(trimmed string) (ordered bool, start int, offset int) {
+	if len(trimmed) >= 2 && strings.ContainsRune("-*+", rune(trimmed[0])) && trimmed[1] == ' ' {
+		return false, 0, 2
+	}
+
+	digits := 0
+	for digits < len(trimmed) && digits < 9 && trimmed[digits] >= '0' && trimmed[digits] <= '9' {
+		digits++
+	}
+
+	if digits == 0 || digits+1 >= len(trimmed) || (trimmed[digits] != '.' && trimmed[digits] != ')') || trimmed[digits+1] != ' ' {
+		return false, 0, 0
+	}
+
+	start, _ = strconv.Atoi(trimmed[:digits])
+	return true, start, digits + 2
+}
+
+func isCodeLanguage(language string) bool {
+	for i := 0; i < len(language); i++ {
+		if !isWordByte(language[i]) && language[i] != '-' && language[i] != '+' && language[i] != '#' {
+			return false
+		}
+	}
+
+	return len(language) <= 30
+}
+
+func runLength(text string, i int, c byte) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == c {
+		n++
+	}
+
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
@@ -0,0 +1,174 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		// Raw HTML is escaped, never passed through
+		{"script tag", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"event handler", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"html in code", "`<b>`", "<p><code>&lt;b&gt;</code></p>\n"},
+		{"html in a fence", "```\n<b>\n```", "<pre><code>&lt;b&gt;\n</code></pre>\n"},
+		{"quotes in a link", `[x](https://example.com/?q="a"&b=1)`, `<p><a href="https://example.com/?q=&#34;a&#34;&amp;b=1" rel="nofollow ugc noopener">x</a></p>` + "\n"},
+
+		// Links to unsafe URLs keep only their text
+		{"javascript link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"javascript link in capitals", "[x](JavaScript:alert(1))", "<p>x</p>\n"},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>\n"},
+		{"protocol relative link", "[x](//evil.example)", "<p>x</p>\n"},
+		{"backslash relative link", `[x](/\evil.example)`, "<p>x</p>\n"},
+		{"site link", "[x](/forum?id=1)", `<p><a href="/forum?id=1" rel="nofollow ugc noopener">x</a></p>` + "\n"},
+		{"anchor link", "[x](#top)", `<p><a href="#top" rel="nofollow ugc noopener">x</a></p>` + "\n"},
+		{"mailto link", "[x](mailto:a@example.com)", `<p><a href="mailto:a@example.com" rel="nofollow ugc noopener">x</a></p>` + "\n"},
+		{"autolink", "see https://example.com/path.", `<p>see <a href="https://example.com/path" rel="nofollow ugc noopener">https://example.com/path</a>.</p>` + "\n"},
+		{"no autolink in a link", "[https://a.example](https://b.example)", `<p><a href="https://b.example" rel="nofollow ugc noopener">https://a.example</a></p>` + "\n"},
+
+		// Mentions
+		{"mention", "hi @alice.", `<p>hi <span class="mention" data-mention="alice">@alice</span>.</p>` + "\n"},
+		{"mention in a code span", "`@alice`", "<p><code>@alice</code></p>\n"},
+		{"mention in a fence", "```\n@alice\n```", "<pre><code>@alice\n</code></pre>\n"},
+		{"email address", "mail alice@example.com", "<p>mail alice@example.com</p>\n"},
+
+		// Emphasis
+		{"emphasis", "**bold** *em* ~~del~~ ***both***", "<p><strong>bold</strong> <em>em</em> <del>del</del> <strong><em>both</em></strong></p>\n"},
+		{"snake case", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"escaped emphasis", `\*not em\*`, "<p>*not em*</p>\n"},
+		{"single tilde", "~a~", "<p>~a~</p>\n"},
+		{"run of four", "****a****", "<p>****a****</p>\n"},
+		{"unclosed", "*a **b", "<p>*a **b</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMarkdown(tt.src); got != tt.want {
+				t.Errorf("RenderMarkdown(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"mentions in order", "@bob and @alice", []string{"bob", "alice"}},
+		{"distinct", "@bob @bob", []string{"bob"}},
+		{"trailing dot", "thanks @first.last.", []string{"first.last"}},
+		{"code span", "`@alice` and @bob", []string{"bob"}},
+		{"fence", "```\n@carol\n```\n@dave", []string{"dave"}},
+		{"indented fence", "  ~~~\n  @carol\n  ~~~", nil},
+		{"email address", "alice@example.com", nil},
+		{"link text", "[@erin](/users/erin)", []string{"erin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractMentions(tt.src)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ExtractMentions(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSafeURL(t *testing.T) {
+	tests := []struct {
+		href string
+		want bool
+	}{
+		{"https://example.com", true},
+		{"HTTP://example.com", true},
+		{"mailto:a@example.com", true},
+		{"/", true},
+		{"/forum?id=1", true},
+		{"#top", true},
+		{"", false},
+		{"javascript:alert(1)", false},
+		{"data:text/html,x", false},
+		{"vbscript:x", false},
+		{"//evil.example", false},
+		{`/\evil.example`, false},
+		{`\\evil.example`, false},
+		{`https:\\evil.example`, false},
+		{"/\t/evil.example", false},
+		{"java\nscript:alert(1)", false},
+		{"forum?id=1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSafeURL(tt.href); got != tt.want {
+			t.Errorf("isSafeURL(%q) = %v, want %v", tt.href, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdownNesting(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		tag  string
+	}{
+		{"quotes", strings.Repeat("> ", 20) + "deep", "<blockquote>"},
+		{"lists", strings.Repeat("- ", 20) + "deep", "<ul>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Count(RenderMarkdown(tt.src), tt.tag); got != maxMarkdownNesting {
+				t.Errorf("got %d %s, want %d", got, tt.tag, maxMarkdownNesting)
+			}
+		})
+	}
+
+	// Links can't be nested
+	got := RenderMarkdown("[[a](/a)](/b)")
+	if strings.Count(got, "<a ") != 1 {
+		t.Errorf("nested links rendered as %q", got)
+	}
+}
+
+// TestRenderMarkdownLinearTime renders inputs that make a naive parser
+// rescan the rest of the text from every opener. They are five times the
+// size a post may be and must still render at once.
+func TestRenderMarkdownLinearTime(t *testing.T) {
+	var codeRuns strings.Builder
+	for n := 1; codeRuns.Len() < 100000; n++ {
+		codeRuns.WriteString(strings.Repeat("`", n) + "a")
+	}
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"brackets", strings.Repeat("[", 100000)},
+		{"link openers", strings.Repeat("[a](", 25000)},
+		{"emphasis openers", strings.Repeat("*a ", 35000)},
+		{"mixed emphasis", strings.Repeat("*_~~**a ", 12500)},
+		{"code runs", codeRuns.String()},
+		{"autolinks", strings.Repeat("http://a", 12500)},
+		{"mentions", strings.Repeat("@a.", 35000)},
+		{"quotes", strings.Repeat(">", 100000)},
+		{"lists", strings.Repeat("- ", 50000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			RenderMarkdown(tt.src)
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("rendering %d bytes took %v", len(tt.src), elapsed)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package lib
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -10,8 +15,74 @@ type RequestHandler struct {
 }
 
 func NewRequestHandler() *RequestHandler {
-	gin := gin.Default()
+	gin := newEngine()
 
 	gin.Use(cors.Default())
 	return &RequestHandler{gin}
 }
+
+// newEngine is gin.Default with a request log that leaves out the tokens
+// sent in query strings
+func newEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(gin.LoggerWithFormatter(requestLogFormatter), gin.Recovery())
+
+	return engine
+}
+
+// redactedQueryParams hold credentials. Browsers' EventSource can only send
+// the access token in the URL, which must not end up in the logs.
+var redactedQueryParams = []string{"access_token"}
+
+// requestLogFormatter writes the same line as gin's default formatter with
+// the credentials in the path redacted
+func requestLogFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactPath(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// redactPath replaces the value of the redacted query params in path. A
+// query string that can't be parsed is left out entirely.
+func redactPath(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return base + "?REDACTED"
+	}
+
+	redacted := false
+	for _, name := range redactedQueryParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return path
+	}
+
+	return base + "?" + query.Encode()
+}
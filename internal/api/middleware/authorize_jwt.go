@@ -4,39 +4,71 @@ import (
 	"net/http"
 
 	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/services"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthorizeJWT() gin.HandlerFunc {
+// AuthMiddleware authorizes requests against the JWT and its session
+type AuthMiddleware struct {
+	userService services.UserService
+}
+
+func NewAuthMiddleware(userService services.UserService) *AuthMiddleware {
+	return &AuthMiddleware{userService}
+}
+
+func (m *AuthMiddleware) AuthorizeJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.authorize(c, c.GetHeader("Authorization"))
+	}
+}
+
+// AuthorizeStream also accepts the token from the access_token query param,
+// since browsers' EventSource cannot set request headers
+func (m *AuthMiddleware) AuthorizeStream() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"status":  401,
-				"message": "Unauthorized",
-				"data":    nil,
-			})
-			return
+			auth = c.Query("access_token")
 		}
 
-		token, err := lib.ValidateJWT(auth)
+		m.authorize(c, auth)
+	}
+}
 
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"status":  401,
-				"message": "Unauthorized",
-				"data":    nil,
-			})
-			return
-		}
+func (m *AuthMiddleware) authorize(c *gin.Context, auth string) {
+	if auth == "" {
+		abortUnauthorized(c)
+		return
+	}
 
-		if claims, ok := token.Claims.(*lib.JWT); ok && token.Valid {
-			c.Set("USER_DATA", claims.Data)
-			c.Next()
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"message": "Unauthorized",
-			})
-		}
+	token, err := lib.ValidateJWT(auth)
+
+	if err != nil {
+		abortUnauthorized(c)
+		return
+	}
+
+	claims, ok := token.Claims.(*lib.JWT)
+	if !ok || !token.Valid {
+		abortUnauthorized(c)
+		return
 	}
+
+	// Reject tokens whose session was revoked, e.g. after logout
+	if err := m.userService.CheckSession(&claims.Data); err != nil {
+		abortUnauthorized(c)
+		return
+	}
+
+	c.Set("USER_DATA", claims.Data)
+	c.Next()
+}
+
+func abortUnauthorized(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"status":  401,
+		"message": "Unauthorized",
+		"data":    nil,
+	})
 }
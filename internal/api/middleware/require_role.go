@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole only lets through users with one of the given roles. It must
+// run after AuthorizeJWT.
+func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := helper.GetUserData(c)
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"status":  403,
+			"message": helper.RoleNotAuthorized,
+			"data":    nil,
+		})
+	}
+}
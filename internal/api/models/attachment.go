@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Attachment is a file uploaded to a forum. It belongs to no post until a
+// thread or reply references it.
+//
+// URL and ThumbnailURL locate the stored files. They are never sent to
+// clients, who download the files through the URLs that check the read
+// policy of the forum instead.
+type Attachment struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ForumID      uint           `json:"forum_id" gorm:"index"`
+	ThreadID     *uint          `json:"thread_id" gorm:"index"`
+	ReplyID      *uint          `json:"reply_id" gorm:"index"`
+	FileName     string         `json:"file_name" gorm:"type:varchar(255)"`
+	ContentType  string         `json:"content_type" gorm:"type:varchar(100)"`
+	Size         int64          `json:"size"`
+	URL          string         `json:"-" gorm:"type:varchar(255)"`
+	ThumbnailURL *string        `json:"-" gorm:"type:varchar(255)"`
+	CreatedBy    uint           `json:"created_by" gorm:"index"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	DownloadURL          string  `json:"url" gorm:"-"`
+	DownloadThumbnailURL *string `json:"thumbnail_url" gorm:"-"`
+}
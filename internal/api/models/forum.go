@@ -7,12 +7,17 @@ import (
 )
 
 type Forum struct {
-	ID               uint           `json:"id" gorm:"primaryKey"`
-	ForumName        string         `json:"forum_name" gorm:"unique;type:varchar(255)"`
-	IntroductionText string         `json:"introduction_text" gorm:"type:text"`
-	ForumImage       *string        `json:"forum_image"`
-	Category         *string        `json:"category"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	ForumName         string         `json:"forum_name" gorm:"unique;type:varchar(255);index:idx_forums_search,class:FULLTEXT"`
+	IntroductionText  string         `json:"introduction_text" gorm:"type:text;index:idx_forums_search,class:FULLTEXT"`
+	ForumImage        *string        `json:"forum_image"`
+	ForumThumbnail    *string        `json:"forum_thumbnail" gorm:"type:varchar(255)"`
+	Category          *string        `json:"category"`
+	Visibility        string         `json:"visibility" gorm:"type:ENUM('public', 'request_to_join', 'invite_only');default:'public'"`
+	AttachmentMaxSize int64          `json:"attachment_max_size" gorm:"default:10485760"`
+	AttachmentTypes   string         `json:"attachment_types" gorm:"type:varchar(255);default:'image/jpeg,image/png,image/gif,application/pdf'"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletionBatch     *string        `json:"-" gorm:"type:varchar(32);index"`
 }
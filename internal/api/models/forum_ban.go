@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ForumBan keeps a removed member out of a forum until it expires or is
+// lifted. A ban without ExpiresAt lasts until it is lifted.
+type ForumBan struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ForumID   uint           `json:"forum_id" gorm:"index"`
+	UserID    uint           `json:"user_id" gorm:"index"`
+	BannedBy  uint           `json:"banned_by"`
+	Reason    string         `json:"reason" gorm:"type:text"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	LiftedAt  *time.Time     `json:"lifted_at"`
+	LiftedBy  *uint          `json:"lifted_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BanAppeal is a banned user's request to have their ban lifted
+type BanAppeal struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	BanID      uint           `json:"ban_id" gorm:"index"`
+	UserID     uint           `json:"user_id" gorm:"index"`
+	Message    string         `json:"message" gorm:"type:text"`
+	Status     string         `json:"status" gorm:"type:ENUM('pending', 'accepted', 'rejected');default:'pending'"`
+	ReviewedBy *uint          `json:"reviewed_by"`
+	ReviewedAt *time.Time     `json:"reviewed_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ForumLeave records a member leaving a forum on their own, kept for the
+// membership statistics
+type ForumLeave struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ForumID   uint      `json:"forum_id" gorm:"index"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	JoinedAt  time.Time `json:"joined_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
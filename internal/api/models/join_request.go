@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JoinRequest is a user's request to join a forum that is not public
+type JoinRequest struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	ForumID    uint           `json:"forum_id" gorm:"index"`
+	UserID     uint           `json:"user_id" gorm:"index"`
+	Message    string         `json:"message" gorm:"type:text"`
+	Status     string         `json:"status" gorm:"type:ENUM('pending', 'approved', 'rejected');default:'pending'"`
+	ReviewedBy *uint          `json:"reviewed_by"`
+	ReviewedAt *time.Time     `json:"reviewed_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// ForumInvite lets whoever holds its code join the forum, until it expires
+// or has been used MaxUses times. A MaxUses of 0 means no limit.
+type ForumInvite struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ForumID   uint           `json:"forum_id" gorm:"index"`
+	CodeHash  string         `json:"-" gorm:"uniqueIndex;type:varchar(64)"`
+	CreatedBy uint           `json:"created_by"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	MaxUses   int            `json:"max_uses"`
+	Uses      int            `json:"uses"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
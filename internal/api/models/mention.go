@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Mention records that a thread, or one of its replies when ReplyID is set,
+// mentions UserID
+type Mention struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"index"`
+	ThreadID    uint      `json:"thread_id" gorm:"index"`
+	ReplyID     *uint     `json:"reply_id" gorm:"index"`
+	MentionedBy uint      `json:"mentioned_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
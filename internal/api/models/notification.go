@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Notification struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	UserID    uint           `json:"user_id" gorm:"index"`
+	ActorID   *uint          `json:"actor_id"`
+	Type      string         `json:"type" gorm:"type:varchar(50)"`
+	Message   string         `json:"message" gorm:"type:text"`
+	ForumID   *uint          `json:"forum_id"`
+	ThreadID  *uint          `json:"thread_id"`
+	ReplyID   *uint          `json:"reply_id"`
+	ReadAt    *time.Time     `json:"read_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
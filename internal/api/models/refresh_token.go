@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	SessionID uint       `json:"session_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;type:varchar(64)"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -8,12 +8,14 @@ import (
 
 type Reply struct {
 	ID                uint           `json:"id" gorm:"primaryKey"`
-	Text              string         `json:"text" gorm:"type:longtext"`
+	Text              string         `json:"text" gorm:"type:longtext;index:idx_replies_search,class:FULLTEXT"`
 	ThreadID          uint           `json:"thread_id"`
+	ParentReplyID     *uint          `json:"parent_reply_id" gorm:"index"`
 	NumberOfUpvotes   int            `json:"number_of_upvotes"`
 	NumberOfDownvotes int            `json:"number_of_downvotes"`
 	CreatedBy         uint           `json:"created_by"`
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletionBatch     *string        `json:"-" gorm:"type:varchar(32);index"`
 }
@@ -7,11 +7,12 @@ import (
 )
 
 type ReplyVote struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	ReplyID   uint           `json:"reply_id"`
-	UserID    uint           `json:"user_id"`
-	Vote      bool           `json:"vote"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	ReplyID       uint           `json:"reply_id" gorm:"uniqueIndex:idx_reply_vote_user"`
+	UserID        uint           `json:"user_id" gorm:"uniqueIndex:idx_reply_vote_user"`
+	Vote          bool           `json:"vote"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletionBatch *string        `json:"-" gorm:"type:varchar(32);index"`
 }
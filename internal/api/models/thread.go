@@ -8,8 +8,8 @@ import (
 
 type Thread struct {
 	ID                uint           `json:"id" gorm:"primaryKey"`
-	Title             string         `json:"title"`
-	Text              string         `json:"text" gorm:"type:longtext"`
+	Title             string         `json:"title" gorm:"type:longtext;index:idx_threads_search,class:FULLTEXT"`
+	Text              string         `json:"text" gorm:"type:longtext;index:idx_threads_search,class:FULLTEXT"`
 	ForumID           uint           `json:"forum_id"`
 	NumberOfUpvotes   int            `json:"number_of_upvotes"`
 	NumberOfDownvotes int            `json:"number_of_downvotes"`
@@ -17,4 +17,5 @@ type Thread struct {
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletionBatch     *string        `json:"-" gorm:"type:varchar(32);index"`
 }
@@ -7,11 +7,12 @@ import (
 )
 
 type ThreadVote struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	ThreadID  uint           `json:"thread_id"`
-	UserID    uint           `json:"user_id"`
-	Vote      bool           `json:"vote"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	ThreadID      uint           `json:"thread_id" gorm:"uniqueIndex:idx_thread_vote_user"`
+	UserID        uint           `json:"user_id" gorm:"uniqueIndex:idx_thread_vote_user"`
+	Vote          bool           `json:"vote"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletionBatch *string        `json:"-" gorm:"type:varchar(32);index"`
 }
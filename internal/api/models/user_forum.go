@@ -7,11 +7,13 @@ import (
 )
 
 type UserForum struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id"`
-	ForumID   uint           `json:"forum_id"`
-	IsRemoved bool           `json:"is_removed" default:"false"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	UserID        uint           `json:"user_id"`
+	ForumID       uint           `json:"forum_id"`
+	IsRemoved     bool           `json:"is_removed" default:"false"`
+	LeftAt        *time.Time     `json:"left_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletionBatch *string        `json:"-" gorm:"type:varchar(32);index"`
 }
@@ -0,0 +1,151 @@
+package realtime
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("realtime",
+	fx.Provide(
+		NewLocalFanOut,
+		NewHub,
+	),
+)
+
+// subscriptionBuffer is how many messages a subscriber may lag behind
+// before messages to it are dropped
+const subscriptionBuffer = 64
+
+// Message is pushed to every client subscribed to its topic
+type Message struct {
+	Topic string      `json:"topic"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+func ForumTopic(forumID uint) string {
+	return fmt.Sprintf("forum:%d", forumID)
+}
+
+func ThreadTopic(threadID uint) string {
+	return fmt.Sprintf("thread:%d", threadID)
+}
+
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// FanOut carries messages to every API instance. The local implementation
+// only knows about this process; one backed by a message broker lets
+// several instances share their clients.
+type FanOut interface {
+	Publish(msg *Message) error
+	// Receive registers the function called with every message published
+	// on any instance
+	Receive(deliver func(msg *Message))
+}
+
+type localFanOut struct {
+	mu       sync.RWMutex
+	delivers []func(msg *Message)
+}
+
+func NewLocalFanOut() FanOut {
+	return &localFanOut{}
+}
+
+func (f *localFanOut) Publish(msg *Message) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, deliver := range f.delivers {
+		deliver(msg)
+	}
+
+	return nil
+}
+
+func (f *localFanOut) Receive(deliver func(msg *Message)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.delivers = append(f.delivers, deliver)
+}
+
+// Subscription receives the messages of its topics on C until it is
+// closed with Hub.Unsubscribe
+type Subscription struct {
+	C      <-chan *Message
+	ch     chan *Message
+	topics []string
+}
+
+// Hub keeps track of the streaming clients connected to this instance and
+// the topics they follow
+type Hub struct {
+	fanOut FanOut
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]bool
+}
+
+func NewHub(fanOut FanOut) *Hub {
+	hub := &Hub{fanOut: fanOut, topics: map[string]map[*Subscription]bool{}}
+	fanOut.Receive(hub.deliver)
+
+	return hub
+}
+
+// Publish sends the message to the subscribers of its topic on every
+// instance
+func (h *Hub) Publish(msg *Message) error {
+	return h.fanOut.Publish(msg)
+}
+
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	ch := make(chan *Message, subscriptionBuffer)
+	sub := &Subscription{C: ch, ch: ch, topics: topics}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = map[*Subscription]bool{}
+		}
+
+		h.topics[topic][sub] = true
+	}
+
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range sub.topics {
+		delete(h.topics[topic], sub)
+
+		if len(h.topics[topic]) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+
+	close(sub.ch)
+}
+
+// deliver hands a message to the local subscribers of its topic. A client
+// too slow to keep up misses messages rather than blocking everyone else.
+func (h *Hub) deliver(msg *Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.topics[msg.Topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+		}
+	}
+}
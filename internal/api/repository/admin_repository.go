@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+type AdminRepository interface {
+	WithTx(tx *gorm.DB) AdminRepository
+	ListAllForums(pagination *request.ReqPagination) ([]response.ResAdminForum, *response.ResPagination, error)
+	GetForumByIDUnscoped(id uint) (*models.Forum, error)
+	RestoreForum(forum *models.Forum) error
+	// ListForumAttachments returns every attachment of the forum, including
+	// the ones of deleted posts
+	ListForumAttachments(forumID uint) ([]models.Attachment, error)
+	HardDeleteForum(forum *models.Forum) error
+	GetSiteStats() (*response.ResAdminStats, error)
+}
+
+type adminRepository struct {
+	db *database.Database
+}
+
+func NewAdminRepository(db *database.Database) AdminRepository {
+	return &adminRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *adminRepository) WithTx(tx *gorm.DB) AdminRepository {
+	return &adminRepository{txDatabase(tx)}
+}
+
+func (r *adminRepository) ListAllForums(pagination *request.ReqPagination) ([]response.ResAdminForum, *response.ResPagination, error) {
+	page, err := newPage(forumPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res []response.ResAdminForum
+
+	// Deleted forums are listed too
+	query := r.db.DB.
+		Table("forums f").
+		Select(`f.id, f.forum_name, f.forum_image, f.category, f.created_at, f.deleted_at,
+			(SELECT COUNT(*) FROM user_forums uf WHERE uf.forum_id = f.id AND uf.is_removed = 0 AND uf.left_at IS NULL AND uf.deleted_at IS NULL) AS number_of_members,
+			(SELECT COUNT(*) FROM threads t WHERE t.forum_id = f.id AND t.deleted_at IS NULL) AS number_of_threads`)
+
+	err = page.apply(query, "f.created_at DESC").Scan(&res).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, res, func(f response.ResAdminForum) uint { return f.ID })
+}
+
+func (r *adminRepository) GetForumByIDUnscoped(id uint) (*models.Forum, error) {
+	var forum models.Forum
+	err := r.db.DB.Unscoped().Where("id = ?", id).First(&forum).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &forum, nil
+}
+
+// RestoreForum brings back the forum and everything its deletion removed
+func (r *adminRepository) RestoreForum(forum *models.Forum) error {
+	return restoreDeleted(r.db.DB, "forums", forum.ID, forum.DeletionBatch)
+}
+
+func (r *adminRepository) ListForumAttachments(forumID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+
+	err := r.db.DB.Unscoped().Where("forum_id = ?", forumID).Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// HardDeleteForum permanently removes the forum and everything that belongs
+// to it
+func (r *adminRepository) HardDeleteForum(forum *models.Forum) error {
+	queries := []string{
+		`DELETE FROM reply_votes WHERE reply_id IN (
+			SELECT r.id FROM replies r INNER JOIN threads t ON t.id = r.thread_id WHERE t.forum_id = ?
+		)`,
+		`DELETE FROM replies WHERE thread_id IN (SELECT id FROM threads WHERE forum_id = ?)`,
+		`DELETE FROM thread_votes WHERE thread_id IN (SELECT id FROM threads WHERE forum_id = ?)`,
+		`DELETE FROM mentions WHERE thread_id IN (SELECT id FROM threads WHERE forum_id = ?)`,
+		`DELETE FROM notifications WHERE forum_id = ?`,
+		`DELETE FROM attachments WHERE forum_id = ?`,
+		`DELETE FROM threads WHERE forum_id = ?`,
+		`DELETE FROM ban_appeals WHERE ban_id IN (SELECT id FROM forum_bans WHERE forum_id = ?)`,
+		`DELETE FROM forum_bans WHERE forum_id = ?`,
+		`DELETE FROM forum_leaves WHERE forum_id = ?`,
+		`DELETE FROM join_requests WHERE forum_id = ?`,
+		`DELETE FROM forum_invites WHERE forum_id = ?`,
+		`DELETE FROM user_forums WHERE forum_id = ?`,
+		`DELETE FROM moderators WHERE forum_id = ?`,
+		`DELETE FROM forums WHERE id = ?`,
+	}
+
+	for _, query := range queries {
+		if err := r.db.DB.Exec(query, forum.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *adminRepository) GetSiteStats() (*response.ResAdminStats, error) {
+	var res response.ResAdminStats
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL) AS total_users,
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND status = 'Inactive') AS inactive_users,
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND role = 'Admin') AS total_admins,
+			(SELECT COUNT(*) FROM forums WHERE deleted_at IS NULL) AS total_forums,
+			(SELECT COUNT(*) FROM forums WHERE deleted_at IS NOT NULL) AS deleted_forums,
+			(SELECT COUNT(*) FROM threads WHERE deleted_at IS NULL) AS total_threads,
+			(SELECT COUNT(*) FROM replies WHERE deleted_at IS NULL) AS total_replies,
+			(SELECT COUNT(*) FROM thread_votes WHERE deleted_at IS NULL) + (SELECT COUNT(*) FROM reply_votes WHERE deleted_at IS NULL) AS total_votes,
+			(SELECT COUNT(*) FROM forum_leaves) AS total_leaves
+	`
+
+	err := r.db.DB.Raw(query).Scan(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	WithTx(tx *gorm.DB) AttachmentRepository
+	CreateAttachment(attachment *models.Attachment) error
+	GetAttachmentByID(id uint) (*models.Attachment, error)
+	// LinkAttachments attaches files the user uploaded to the forum and
+	// that no post uses yet to either a thread or a reply
+	LinkAttachments(ids []uint, forumID uint, userID uint, threadID *uint, replyID *uint) error
+}
+
+type attachmentRepository struct {
+	db *database.Database
+}
+
+func NewAttachmentRepository(db *database.Database) AttachmentRepository {
+	return &attachmentRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *attachmentRepository) WithTx(tx *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{txDatabase(tx)}
+}
+
+func (r *attachmentRepository) CreateAttachment(attachment *models.Attachment) error {
+	if err := r.db.DB.Create(attachment).Error; err != nil {
+		return err
+	}
+
+	setDownloadURLs(attachment)
+
+	return nil
+}
+
+func (r *attachmentRepository) GetAttachmentByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+
+	err := r.db.DB.Where("id = ?", id).First(&attachment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	setDownloadURLs(&attachment)
+
+	return &attachment, nil
+}
+
+func (r *attachmentRepository) LinkAttachments(ids []uint, forumID uint, userID uint, threadID *uint, replyID *uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	result := r.db.DB.
+		Model(&models.Attachment{}).
+		Where("id IN ?", ids).
+		Where("forum_id = ? AND created_by = ?", forumID, userID).
+		Where("thread_id IS NULL AND reply_id IS NULL").
+		Updates(map[string]interface{}{
+			"thread_id": threadID,
+			"reply_id":  replyID,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Every attachment must be available, otherwise the whole post is
+	// rejected
+	if result.RowsAffected != int64(len(ids)) {
+		return fmt.Errorf(helper.AttachmentNotAvailable)
+	}
+
+	return nil
+}
+
+// loadAttachments fills in the attachments of a thread and of a tree of its
+// replies. thread may be nil when only replies are loaded.
+func loadAttachments(db *gorm.DB, thread *response.ResThreadField, replies []response.ResReplyField) error {
+	byReply := map[uint]*response.ResReplyField{}
+
+	var collect func(replies []response.ResReplyField)
+	collect = func(replies []response.ResReplyField) {
+		for i := range replies {
+			replies[i].Attachments = []models.Attachment{}
+			byReply[replies[i].ID] = &replies[i]
+			collect(replies[i].Children)
+		}
+	}
+	collect(replies)
+
+	ids := make([]uint, 0, len(byReply))
+	for id := range byReply {
+		ids = append(ids, id)
+	}
+
+	query := db.Where("reply_id IN ?", ids)
+	if thread != nil {
+		thread.Attachments = []models.Attachment{}
+		query = db.Where("thread_id = ? OR reply_id IN ?", thread.ID, ids)
+	} else if len(ids) == 0 {
+		return nil
+	}
+
+	var attachments []models.Attachment
+	if err := query.Order("id").Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		setDownloadURLs(&attachment)
+
+		if attachment.ReplyID != nil {
+			reply := byReply[*attachment.ReplyID]
+			reply.Attachments = append(reply.Attachments, attachment)
+		} else {
+			thread.Attachments = append(thread.Attachments, attachment)
+		}
+	}
+
+	return nil
+}
+
+// setDownloadURLs points the attachment's URLs to the endpoint that checks
+// the read policy before serving the stored files
+func setDownloadURLs(attachment *models.Attachment) {
+	attachment.DownloadURL = fmt.Sprintf("%s?id=%d", constants.AttachmentPath, attachment.ID)
+
+	if attachment.ThumbnailURL != nil {
+		thumbnailURL := attachment.DownloadURL + "&thumbnail=true"
+		attachment.DownloadThumbnailURL = &thumbnailURL
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+type BanRepository interface {
+	WithTx(tx *gorm.DB) BanRepository
+	CreateBan(ban *models.ForumBan) error
+	// GetActiveBan returns the ban that currently keeps the user out of the
+	// forum, if any
+	GetActiveBan(forumID uint, userID uint) (*models.ForumBan, error)
+	GetBanByID(id uint) (*models.ForumBan, error)
+	LiftBan(ban *models.ForumBan, userID uint) error
+	ListActiveBans(forumID uint, pagination *request.ReqPagination) ([]response.ResForumBan, *response.ResPagination, error)
+	CreateAppeal(appeal *models.BanAppeal) error
+	GetPendingAppeal(banID uint) (*models.BanAppeal, error)
+	GetAppealByID(id uint) (*models.BanAppeal, error)
+	ReviewAppeal(appeal *models.BanAppeal, status string, userID uint) error
+}
+
+type banRepository struct {
+	db *database.Database
+}
+
+func NewBanRepository(db *database.Database) BanRepository {
+	return &banRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *banRepository) WithTx(tx *gorm.DB) BanRepository {
+	return &banRepository{txDatabase(tx)}
+}
+
+// activeBanSQL tells whether the ban aliased by prefix is in force at the
+// time bound to its placeholder
+func activeBanSQL(prefix string) string {
+	return prefix + "lifted_at IS NULL AND (" + prefix + "expires_at IS NULL OR " + prefix + "expires_at > ?)"
+}
+
+var banPageSource = pageSource{
+	table: "forum_bans b",
+	id:    "b.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+	},
+}
+
+func (r *banRepository) CreateBan(ban *models.ForumBan) error {
+	return r.db.DB.Create(ban).Error
+}
+
+func (r *banRepository) GetActiveBan(forumID uint, userID uint) (*models.ForumBan, error) {
+	var ban models.ForumBan
+	err := r.db.DB.
+		Where("forum_id = ?", forumID).
+		Where("user_id = ?", userID).
+		Where(activeBanSQL(""), time.Now()).
+		Last(&ban).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+func (r *banRepository) GetBanByID(id uint) (*models.ForumBan, error) {
+	var ban models.ForumBan
+	err := r.db.DB.Where("id = ?", id).First(&ban).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+func (r *banRepository) LiftBan(ban *models.ForumBan, userID uint) error {
+	return r.db.DB.Model(&ban).Updates(map[string]interface{}{
+		"lifted_at": time.Now(),
+		"lifted_by": userID,
+	}).Error
+}
+
+func (r *banRepository) ListActiveBans(forumID uint, pagination *request.ReqPagination) ([]response.ResForumBan, *response.ResPagination, error) {
+	page, err := newPage(banPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("b.id DESC")
+
+	// a holds the latest appeal of each ban
+	query := `
+		SELECT b.id, b.user_id, u.name AS user_name, u.profile_image, b.reason,
+			b.banned_by, m.name AS banned_by_name, b.expires_at, b.created_at,
+			a.id AS appeal_id, a.status AS appeal_status, a.message AS appeal_message
+		FROM forum_bans b
+		INNER JOIN users u ON u.id = b.user_id
+		INNER JOIN users m ON m.id = b.banned_by
+		LEFT JOIN ban_appeals a ON a.id = (
+			SELECT MAX(la.id) FROM ban_appeals la WHERE la.ban_id = b.id AND la.deleted_at IS NULL
+		)
+		WHERE b.forum_id = ?
+		AND b.deleted_at IS NULL
+		AND ` + activeBanSQL("b.") + cursorWhere + orderBy
+
+	args := append(append([]interface{}{forumID, time.Now()}, cursorArgs...), orderArgs...)
+
+	var res []response.ResForumBan
+	if err := r.db.DB.Raw(query, args...).Scan(&res).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, res, func(b response.ResForumBan) uint { return b.ID })
+}
+
+func (r *banRepository) CreateAppeal(appeal *models.BanAppeal) error {
+	return r.db.DB.Create(appeal).Error
+}
+
+func (r *banRepository) GetPendingAppeal(banID uint) (*models.BanAppeal, error) {
+	var appeal models.BanAppeal
+	err := r.db.DB.Where("ban_id = ?", banID).Where("status = ?", constants.BanAppealPending).First(&appeal).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &appeal, nil
+}
+
+func (r *banRepository) GetAppealByID(id uint) (*models.BanAppeal, error) {
+	var appeal models.BanAppeal
+	err := r.db.DB.Where("id = ?", id).First(&appeal).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &appeal, nil
+}
+
+func (r *banRepository) ReviewAppeal(appeal *models.BanAppeal, status string, userID uint) error {
+	return r.db.DB.Model(&appeal).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": userID,
+		"reviewed_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A deletion batch ties together the rows soft-deleted by one cascading
+// delete, so that a restore brings back exactly those rows and leaves alone
+// the ones that were deleted on their own before.
+
+// batchDelete is one step of a cascading delete: the live rows of table
+// matching where
+type batchDelete struct {
+	table string
+	where string
+}
+
+// deletionBatchTables lists every table whose rows can be part of a batch
+var deletionBatchTables = []string{
+	"forums",
+	"threads",
+	"replies",
+	"thread_votes",
+	"reply_votes",
+	"user_forums",
+	"moderators",
+}
+
+func newDeletionBatch() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// softDeleteBatch runs the steps of a cascading delete under a new batch,
+// binding arg to the placeholder of every step
+func softDeleteBatch(db *gorm.DB, arg interface{}, steps []batchDelete) error {
+	batch, err := newDeletionBatch()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, step := range steps {
+		query := "UPDATE " + step.table + " SET deleted_at = ?, deletion_batch = ? WHERE deleted_at IS NULL AND " + step.where
+		if err := db.Exec(query, now, batch, arg).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreBatch brings back the rows deleted with batch
+func restoreBatch(db *gorm.DB, batch string) error {
+	for _, table := range deletionBatchTables {
+		query := "UPDATE " + table + " SET deleted_at = NULL, deletion_batch = NULL WHERE deletion_batch = ?"
+		if err := db.Exec(query, batch).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreDeleted brings back a row of table along with the rows deleted in
+// the same batch. Rows deleted before batches existed have no batch and are
+// restored alone.
+func restoreDeleted(db *gorm.DB, table string, id uint, batch *string) error {
+	if batch != nil {
+		return restoreBatch(db, *batch)
+	}
+
+	return db.Exec("UPDATE "+table+" SET deleted_at = NULL WHERE id = ?", id).Error
+}
@@ -2,30 +2,50 @@ package repository
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/drdofx/talk-parmad/internal/api/constants"
 	"github.com/drdofx/talk-parmad/internal/api/database"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
 	"github.com/drdofx/talk-parmad/internal/api/models"
 	"github.com/drdofx/talk-parmad/internal/api/request"
 	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ForumRepository interface {
+	WithTx(tx *gorm.DB) ForumRepository
 	GetForumByName(name string) (*models.Forum, error)
 	GetForumById(id uint) (*models.Forum, error)
 	GetUserForumByID(forumID uint, userID uint) (*models.UserForum, error)
 	GetModeratorByID(forumID uint, userID uint) (*models.Moderator, error)
 	CreateForum(req *request.ReqSaveForum, user *lib.UserData) (*models.Forum, error)
+	GetHeadModerator(forumID uint) (*models.Moderator, error)
 	CreateModeratorHead(forum *models.Forum, user *lib.UserData) (*models.Moderator, error)
-	CreateUserForum(forum *models.Forum, user *lib.UserData) (*models.UserForum, error)
-	ListUserForum(user *lib.UserData) ([]models.Forum, error)
-	DiscoverForum(user *lib.UserData) ([]models.Forum, error)
-	DetailForum(user *lib.UserData, forumID uint) (*response.ResDetailForum, error)
-	ListThreadForumHome(userID uint) (*[]response.ResThreadForumHome, error)
+	CreateModerator(forumID uint, userID uint, rank string, nickname *string) (*models.Moderator, error)
+	UpdateModeratorRank(moderator *models.Moderator, rank string) error
+	DeleteModerator(moderator *models.Moderator) error
+	ListModerators(forumID uint) ([]response.ResModerator, error)
+	CreateUserForum(forum *models.Forum, userID uint) (*models.UserForum, error)
+	CountMembers(forumID uint) (int64, error)
+	ListUserForum(user *lib.UserData, pagination *request.ReqPagination) ([]models.Forum, *response.ResPagination, error)
+	ListMemberForums(userID uint, viewerID uint) ([]models.Forum, error)
+	DiscoverForum(user *lib.UserData, pagination *request.ReqPagination) ([]models.Forum, *response.ResPagination, error)
+	DetailForum(user *lib.UserData, forumID uint, pagination *request.ReqPagination) (*response.ResDetailForum, *response.ResPagination, error)
+	ListThreadForumHome(userID uint, pagination *request.ReqPagination) (*[]response.ResThreadForumHome, *response.ResPagination, error)
 	UpdateForum(forum *models.Forum, req *request.ReqEditForum) (*models.Forum, error)
+	UpdateForumImage(forum *models.Forum, image string, thumbnail string) error
 	DeleteForum(forum *models.Forum) error
+	GetDeletedForumByID(id uint) (*models.Forum, error)
+	RestoreForum(forum *models.Forum) error
+	GetDeletedForumModerator(forum *models.Forum, userID uint) (*models.Moderator, error)
+	ListSearchDocuments(forumID uint) ([]SearchDocument, error)
 	RemoveFromForum(userForum *models.UserForum) error
-	SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, error)
+	LeaveForum(userForum *models.UserForum) error
+	SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, *response.ResPagination, error)
+	ListVisibleForumIDs(userID uint) ([]uint, error)
 }
 
 type forumRepository struct {
@@ -36,6 +56,11 @@ func NewForumRepository(db *database.Database) ForumRepository {
 	return &forumRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *forumRepository) WithTx(tx *gorm.DB) ForumRepository {
+	return &forumRepository{txDatabase(tx)}
+}
+
 func (r *forumRepository) GetForumByName(name string) (*models.Forum, error) {
 	var forum models.Forum
 	err := r.db.DB.Where("forum_name = ?", name).First(&forum).Error
@@ -58,7 +83,7 @@ func (r *forumRepository) GetForumById(id uint) (*models.Forum, error) {
 
 func (r *forumRepository) GetUserForumByID(forumID uint, userID uint) (*models.UserForum, error) {
 	var userForum models.UserForum
-	err := r.db.DB.Where("user_id = ?", userID).Where("forum_id = ?", forumID).Where("is_removed = ?", false).First(&userForum).Error
+	err := r.db.DB.Where("user_id = ?", userID).Where("forum_id = ?", forumID).Where("is_removed = ?", false).Where("left_at IS NULL").First(&userForum).Error
 	if err != nil {
 		return nil, err
 	}
@@ -76,11 +101,26 @@ func (r *forumRepository) GetModeratorByID(forumID uint, userID uint) (*models.M
 	return &moderator, nil
 }
 
+func (r *forumRepository) GetHeadModerator(forumID uint) (*models.Moderator, error) {
+	var moderator models.Moderator
+	err := r.db.DB.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("forum_id = ?", forumID).
+		Where("`rank` = ?", constants.ModeratorRankHead).
+		First(&moderator).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &moderator, nil
+}
+
 func (r *forumRepository) CreateForum(req *request.ReqSaveForum, user *lib.UserData) (*models.Forum, error) {
 	forum := &models.Forum{
 		ForumName:        req.ForumName,
 		IntroductionText: req.IntroductionText,
 		Category:         &req.Category,
+		Visibility:       req.Visibility,
 	}
 
 	err := r.db.DB.Create(&forum).Error
@@ -96,7 +136,7 @@ func (r *forumRepository) CreateModeratorHead(forum *models.Forum, user *lib.Use
 	moderator := &models.Moderator{
 		ForumID:  forum.ID,
 		UserID:   user.UserID,
-		Rank:     "Head",
+		Rank:     constants.ModeratorRankHead,
 		Nickname: &user.Name,
 	}
 
@@ -109,10 +149,55 @@ func (r *forumRepository) CreateModeratorHead(forum *models.Forum, user *lib.Use
 	return moderator, nil
 }
 
-func (r *forumRepository) CreateUserForum(forum *models.Forum, user *lib.UserData) (*models.UserForum, error) {
+func (r *forumRepository) CreateModerator(forumID uint, userID uint, rank string, nickname *string) (*models.Moderator, error) {
+	moderator := &models.Moderator{
+		ForumID:  forumID,
+		UserID:   userID,
+		Rank:     rank,
+		Nickname: nickname,
+	}
+
+	err := r.db.DB.Create(&moderator).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return moderator, nil
+}
+
+func (r *forumRepository) UpdateModeratorRank(moderator *models.Moderator, rank string) error {
+	return r.db.DB.Model(&moderator).Update("rank", rank).Error
+}
+
+func (r *forumRepository) DeleteModerator(moderator *models.Moderator) error {
+	return r.db.DB.Delete(&moderator).Error
+}
+
+func (r *forumRepository) ListModerators(forumID uint) ([]response.ResModerator, error) {
+	var res []response.ResModerator
+
+	query := `
+		SELECT m.id, m.user_id, u.name AS user_name, u.profile_image, m.nickname, m.rank, m.created_at
+		FROM moderators AS m
+		INNER JOIN users AS u ON u.id = m.user_id
+		WHERE m.forum_id = ?
+		AND m.deleted_at IS NULL
+		ORDER BY m.rank = 'Head' DESC, m.created_at ASC
+	`
+
+	err := r.db.DB.Raw(query, forumID).Scan(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *forumRepository) CreateUserForum(forum *models.Forum, userID uint) (*models.UserForum, error) {
 	userForum := &models.UserForum{
 		ForumID: forum.ID,
-		UserID:  user.UserID,
+		UserID:  userID,
 	}
 
 	err := r.db.DB.Create(&userForum).Error
@@ -124,31 +209,67 @@ func (r *forumRepository) CreateUserForum(forum *models.Forum, user *lib.UserDat
 	return userForum, nil
 }
 
-func (r *forumRepository) ListUserForum(user *lib.UserData) ([]models.Forum, error) {
+func (r *forumRepository) CountMembers(forumID uint) (int64, error) {
+	var count int64
+
+	query := `
+		SELECT COUNT(uf.user_id) AS number_of_members
+		FROM user_forums AS uf
+		WHERE uf.forum_id = ?
+		AND uf.is_removed = 0
+		AND uf.left_at IS NULL
+		AND uf.deleted_at IS NULL
+	`
+
+	err := r.db.DB.Raw(query, forumID).Scan(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ListUserForum lists the forums the user joined. A nil pagination returns
+// every forum, which is what the profile page needs.
+func (r *forumRepository) ListUserForum(user *lib.UserData, pagination *request.ReqPagination) ([]models.Forum, *response.ResPagination, error) {
+	page, err := newPage(forumPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var forums []models.Forum
-	err := r.db.DB.
+	query := r.db.DB.
 		Table("user_forums as uf").
 		Select("f.*").
 		Joins("inner join forums as f on f.id = uf.forum_id").
 		Where("uf.user_id = ?", user.UserID).
 		Where("uf.is_removed = ?", false).
-		Scan(&forums).Error
+		Where("uf.left_at IS NULL").
+		Where("f.deleted_at IS NULL")
 
+	err = page.apply(query, "uf.created_at ASC").Scan(&forums).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return forums, nil
+	return paginate(r.db.DB, page, forums, func(f models.Forum) uint { return f.ID })
 }
 
-func (r *forumRepository) DiscoverForum(user *lib.UserData) ([]models.Forum, error) {
+// ListMemberForums lists the forums the user is a member of that viewer may
+// read, in the order the user joined them
+func (r *forumRepository) ListMemberForums(userID uint, viewerID uint) ([]models.Forum, error) {
 	var forums []models.Forum
 	err := r.db.DB.
-		Table("forums").
-		Where("forums.id NOT IN (SELECT forum_id FROM user_forums WHERE user_id = ?)", user.UserID).
-		Where("forums.deleted_at IS NULL").
+		Table("user_forums as uf").
+		Select("f.*").
+		Joins("inner join forums as f on f.id = uf.forum_id").
+		Where("uf.user_id = ?", userID).
+		Where("uf.is_removed = ?", false).
+		Where("uf.left_at IS NULL").
+		Where("uf.deleted_at IS NULL").
+		Where(readableForumSQL("f"), viewerID).
+		Order("uf.created_at ASC").
 		Scan(&forums).Error
-
 	if err != nil {
 		return nil, err
 	}
@@ -156,9 +277,37 @@ func (r *forumRepository) DiscoverForum(user *lib.UserData) ([]models.Forum, err
 	return forums, nil
 }
 
-func (r *forumRepository) DetailForum(user *lib.UserData, forumID uint) (*response.ResDetailForum, error) {
+func (r *forumRepository) DiscoverForum(user *lib.UserData, pagination *request.ReqPagination) ([]models.Forum, *response.ResPagination, error) {
+	page, err := newPage(forumPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var forums []models.Forum
+	query := r.db.DB.
+		Table("forums as f").
+		Select("f.*").
+		Where("f.id NOT IN (SELECT forum_id FROM user_forums WHERE user_id = ? AND is_removed = 0 AND left_at IS NULL AND deleted_at IS NULL)", user.UserID).
+		Where("f.id NOT IN (SELECT b.forum_id FROM forum_bans b WHERE b.user_id = ? AND b.deleted_at IS NULL AND "+activeBanSQL("b.")+")", user.UserID, time.Now()).
+		Where("f.visibility <> ?", constants.ForumVisibilityInviteOnly).
+		Where("f.deleted_at IS NULL")
+
+	err = page.apply(query, "f.id ASC").Scan(&forums).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, forums, func(f models.Forum) uint { return f.ID })
+}
+
+func (r *forumRepository) DetailForum(user *lib.UserData, forumID uint, pagination *request.ReqPagination) (*response.ResDetailForum, *response.ResPagination, error) {
 	var res response.ResDetailForum
 
+	page, err := newPage(threadPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	forumQuery := `
 		SELECT *
 		FROM forums
@@ -169,33 +318,37 @@ func (r *forumRepository) DetailForum(user *lib.UserData, forumID uint) (*respon
 	// Execute forum query
 	forumRows, err := r.db.DB.Raw(forumQuery, forumID).Rows()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer forumRows.Close()
 
 	// Scan forum data
 	if !forumRows.Next() {
-		return nil, errors.New("forum not found")
+		return nil, nil, errors.New("forum not found")
 	}
 
 	err = r.db.DB.ScanRows(forumRows, &res.ForumData)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("t.created_at DESC")
+
 	threadQuery := `
 		SELECT t.id, t.title, t.text, t.created_at, u.name AS created_by, u.profile_image AS created_by_image
 		FROM threads t
 		INNER JOIN users AS u ON u.id = t.created_by
 		WHERE forum_id = ?
 		AND t.deleted_at IS NULL
-		ORDER BY t.created_at DESC
-	`
+	` + cursorWhere + orderBy
+
+	threadArgs := append(append([]interface{}{forumID}, cursorArgs...), orderArgs...)
 
 	// Execute thread query
-	threadRows, err := r.db.DB.Raw(threadQuery, forumID).Rows()
+	threadRows, err := r.db.DB.Raw(threadQuery, threadArgs...).Rows()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer threadRows.Close()
 
@@ -204,34 +357,51 @@ func (r *forumRepository) DetailForum(user *lib.UserData, forumID uint) (*respon
 		var t response.ResDetailForumThreads
 		err = r.db.DB.ScanRows(threadRows, &t)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		res.ThreadData = append(res.ThreadData, t)
 	}
 
-	// Calculate total threads count
-	res.TotalThreads = len(res.ThreadData)
+	var pageRes *response.ResPagination
+	res.ThreadData, pageRes, err = paginate(r.db.DB, page, res.ThreadData, func(t response.ResDetailForumThreads) uint { return t.ID })
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Calculate number of members
-	membersQuery := `
-		SELECT COUNT(uf.user_id) AS number_of_members
-		FROM user_forums AS uf
-		WHERE uf.forum_id = ?
-		AND is_removed = 0
+	// Count every thread of the forum, not only the current page
+	totalQuery := `
+		SELECT COUNT(*)
+		FROM threads t
+		WHERE t.forum_id = ?
+		AND t.deleted_at IS NULL
 	`
 
-	err = r.db.DB.Raw(membersQuery, forumID).Scan(&res.NumberOfMembers).Error
+	err = r.db.DB.Raw(totalQuery, forumID).Scan(&res.TotalThreads).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Calculate number of members
+	res.NumberOfMembers, err = r.CountMembers(forumID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &res, nil
+	return &res, pageRes, nil
 }
 
-func (r *forumRepository) ListThreadForumHome(userID uint) (*[]response.ResThreadForumHome, error) {
+func (r *forumRepository) ListThreadForumHome(userID uint, pagination *request.ReqPagination) (*[]response.ResThreadForumHome, *response.ResPagination, error) {
 	var res []response.ResThreadForumHome
 
+	page, err := newPage(threadPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("t.created_at DESC")
+
 	query := `
 		SELECT uf.user_id, u.name AS user_name, f.forum_name, f.forum_image, f.id AS forum_id, t.id AS thread_id, t.title, t.text
 		FROM user_forums AS uf
@@ -240,26 +410,24 @@ func (r *forumRepository) ListThreadForumHome(userID uint) (*[]response.ResThrea
 		INNER JOIN threads AS t ON t.forum_id = f.id
 		WHERE uf.user_id = ?
 		AND uf.is_removed = 0
-		ORDER BY t.created_at DESC
-	`
+		AND uf.left_at IS NULL
+		AND ` + readableForumSQL("f") + `
+		AND t.deleted_at IS NULL
+	` + cursorWhere + orderBy
+
+	args := append(append([]interface{}{userID, userID}, cursorArgs...), orderArgs...)
 
-	rows, err := r.db.DB.Raw(query, userID).Rows()
+	err = r.db.DB.Raw(query, args...).Scan(&res).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	defer rows.Close()
-
-	// loop through all rows
-	for rows.Next() {
-		// Scan the row data into variables
-		err = r.db.DB.ScanRows(rows, &res)
-		if err != nil {
-			return nil, err
-		}
+	res, pageRes, err := paginate(r.db.DB, page, res, func(t response.ResThreadForumHome) uint { return t.ThreadID })
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &res, nil
+	return &res, pageRes, nil
 }
 
 func (r *forumRepository) UpdateForum(forum *models.Forum, req *request.ReqEditForum) (*models.Forum, error) {
@@ -272,14 +440,65 @@ func (r *forumRepository) UpdateForum(forum *models.Forum, req *request.ReqEditF
 	return forum, nil
 }
 
+func (r *forumRepository) UpdateForumImage(forum *models.Forum, image string, thumbnail string) error {
+	return r.db.DB.Model(&forum).Updates(map[string]interface{}{
+		"forum_image":     image,
+		"forum_thumbnail": thumbnail,
+	}).Error
+}
+
+// DeleteForum soft-deletes the forum together with its threads, replies,
+// votes, members and moderators, all in one deletion batch
 func (r *forumRepository) DeleteForum(forum *models.Forum) error {
-	err := r.db.DB.Delete(&forum).Error
+	return softDeleteBatch(r.db.DB, forum.ID, []batchDelete{
+		{"reply_votes", "reply_id IN (SELECT r.id FROM replies r INNER JOIN threads t ON t.id = r.thread_id WHERE t.forum_id = ?)"},
+		{"replies", "thread_id IN (SELECT id FROM threads WHERE forum_id = ?)"},
+		{"thread_votes", "thread_id IN (SELECT id FROM threads WHERE forum_id = ?)"},
+		{"threads", "forum_id = ?"},
+		{"user_forums", "forum_id = ?"},
+		{"moderators", "forum_id = ?"},
+		{"forums", "id = ?"},
+	})
+}
 
+func (r *forumRepository) GetDeletedForumByID(id uint) (*models.Forum, error) {
+	var forum models.Forum
+	err := r.db.DB.Unscoped().Where("id = ?", id).Where("deleted_at IS NOT NULL").First(&forum).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &forum, nil
+}
+
+// RestoreForum brings back the forum and everything its deletion removed
+func (r *forumRepository) RestoreForum(forum *models.Forum) error {
+	return restoreDeleted(r.db.DB, "forums", forum.ID, forum.DeletionBatch)
+}
+
+// GetDeletedForumModerator returns the moderator row of the user that was
+// deleted together with the forum. Forums deleted before deletion batches
+// existed kept their moderators.
+func (r *forumRepository) GetDeletedForumModerator(forum *models.Forum, userID uint) (*models.Moderator, error) {
+	query := r.db.DB.Unscoped().Where("user_id = ?", userID).Where("forum_id = ?", forum.ID)
+	if forum.DeletionBatch != nil {
+		query = query.Where("deletion_batch = ?", *forum.DeletionBatch)
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	var moderator models.Moderator
+	if err := query.First(&moderator).Error; err != nil {
+		return nil, err
+	}
+
+	return &moderator, nil
+}
+
+// ListSearchDocuments returns the threads and replies of the forum as the
+// search index sees them
+func (r *forumRepository) ListSearchDocuments(forumID uint) ([]SearchDocument, error) {
+	return listSearchDocuments(r.db.DB, forumID)
 }
 
 func (r *forumRepository) RemoveFromForum(userForum *models.UserForum) error {
@@ -292,29 +511,125 @@ func (r *forumRepository) RemoveFromForum(userForum *models.UserForum) error {
 	return nil
 }
 
-func (r *forumRepository) SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, error) {
+// LeaveForum ends the membership and records the leave
+func (r *forumRepository) LeaveForum(userForum *models.UserForum) error {
+	now := time.Now()
+
+	err := r.db.DB.Model(&userForum).Update("left_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	return r.db.DB.Create(&models.ForumLeave{
+		ForumID:   userForum.ForumID,
+		UserID:    userForum.UserID,
+		JoinedAt:  userForum.CreatedAt,
+		CreatedAt: now,
+	}).Error
+}
+
+// readableForumSQL is the read policy of the services in SQL. It holds for
+// the forum aliased by alias when the user bound to its placeholder may read
+// its content: the forum is not deleted, and is public or has the user as a
+// member.
+func readableForumSQL(alias string) string {
+	return "(" + alias + ".deleted_at IS NULL AND (" + alias + ".visibility = '" + constants.ForumVisibilityPublic + "' OR " + alias + `.id IN (
+		SELECT rm.forum_id FROM user_forums rm
+		WHERE rm.user_id = ? AND rm.is_removed = 0 AND rm.left_at IS NULL AND rm.deleted_at IS NULL
+	)))`
+}
+
+// ListVisibleForumIDs returns the forums whose content the user may see
+func (r *forumRepository) ListVisibleForumIDs(userID uint) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.DB.
+		Table("forums f").
+		Where(readableForumSQL("f"), userID).
+		Pluck("f.id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// SearchForum matches the forum name and introduction text against the
+// search text, by FULLTEXT prefix match, by substring and by sound for
+// misspelled names. Results are ranked by relevance and then member count.
+func (r *forumRepository) SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, *response.ResPagination, error) {
 	var res []response.ResSearchForum
 
+	text := strings.TrimSpace(req.ForumName)
+	terms := fulltextTerms(text)
+
+	source := forumPageSource
+	source.defaultSort = request.SortRelevance
+	source.sorts = map[string][]sortKey{request.SortRelevance: {forumMembersKey}}
+	for sort, keys := range forumPageSource.sorts {
+		source.sorts[sort] = keys
+	}
+
 	query := `
-		SELECT id, forum_name, forum_image, category
-		FROM forums
-		WHERE deleted_at IS NULL
+		SELECT f.id, f.forum_name, f.forum_image, f.category, f.introduction_text,
+			` + forumMembersKey.expr + ` AS number_of_members
+		FROM forums f
+		WHERE f.deleted_at IS NULL
+		AND f.visibility <> ?
 	`
+	args := []interface{}{constants.ForumVisibilityInviteOnly}
+
+	if text != "" {
+		// the name match boosts the FULLTEXT score so that a forum whose name
+		// starts with the text comes before one merely mentioning it
+		relevance := sortKey{
+			expr: `CAST(ROUND(MATCH(f.forum_name, f.introduction_text) AGAINST (? IN BOOLEAN MODE) * 1000)
+				+ CASE WHEN f.forum_name LIKE ? THEN 2000 WHEN f.forum_name LIKE ? THEN 1000 ELSE 0 END AS SIGNED)`,
+			args: []interface{}{booleanPrefixQuery(terms), likePrefix(text), likeContains(text)},
+		}
+		source.sorts[request.SortRelevance] = []sortKey{relevance, forumMembersKey}
+
+		query += `
+			AND (
+				f.forum_name LIKE ?
+				OR f.introduction_text LIKE ?
+				OR SOUNDEX(f.forum_name) = SOUNDEX(?)
+		`
+		args = append(args, likeContains(text), likeContains(text), text)
+
+		if len(terms) > 0 {
+			query += ` OR MATCH(f.forum_name, f.introduction_text) AGAINST (? IN BOOLEAN MODE)`
+			args = append(args, booleanPrefixQuery(terms))
+		}
 
-	if req.ForumName != "" {
-		query += ` AND forum_name LIKE '%` + req.ForumName + `%'`
+		query += `)`
 	}
 
 	if req.Category != "" {
-		query += ` AND category = '` + req.Category + `'`
+		query += ` AND f.category = ?`
+		args = append(args, req.Category)
+	}
+
+	page, err := newPage(source, &req.ReqPagination)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	err := r.db.DB.Raw(query).Scan(&res).Error
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("f.id ASC")
 
+	query += cursorWhere + orderBy
+	args = append(append(args, cursorArgs...), orderArgs...)
+
+	err = r.db.DB.Raw(query, args...).Scan(&res).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &res, nil
+	res, pageRes, err := paginate(r.db.DB, page, res, func(f response.ResSearchForum) uint { return f.ForumID })
+	if err != nil {
+		return nil, nil, err
+	}
 
+	return &res, pageRes, nil
 }
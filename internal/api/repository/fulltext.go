@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// fulltextTerms splits free text into the words a FULLTEXT query is built
+// from. Anything that is not a letter or a digit is a separator, which also
+// strips the boolean mode operators out of user input.
+func fulltextTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// booleanPrefixQuery builds a boolean mode AGAINST expression matching any
+// of the terms, each one as a prefix
+func booleanPrefixQuery(terms []string) string {
+	words := make([]string, 0, len(terms))
+	for _, term := range terms {
+		words = append(words, term+"*")
+	}
+
+	return strings.Join(words, " ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeContains returns a LIKE pattern matching text anywhere in a column
+func likeContains(text string) string {
+	return "%" + likeEscaper.Replace(text) + "%"
+}
+
+// likePrefix returns a LIKE pattern matching columns starting with text
+func likePrefix(text string) string {
+	return likeEscaper.Replace(text) + "%"
+}
+
+const snippetLength = 200
+
+// highlightSnippet cuts a window of text around the first word matching one
+// of the terms and wraps every matching word in <mark>. The rest of the
+// snippet is HTML escaped so it can be rendered as is.
+func highlightSnippet(text string, terms []string) string {
+	runes := []rune(text)
+
+	type word struct{ start, end int }
+	var matches []word
+
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && isWordRune(runes[i]) {
+			i++
+		}
+
+		if matchesTerm(strings.ToLower(string(runes[start:i])), terms) {
+			matches = append(matches, word{start, i})
+		}
+	}
+
+	from := 0
+	if len(matches) > 0 && matches[0].start > snippetLength/4 {
+		from = matches[0].start - snippetLength/4
+	}
+
+	to := from + snippetLength
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+
+	pos := from
+	for _, m := range matches {
+		if m.end <= from || m.start >= to {
+			continue
+		}
+
+		start, end := maxInt(m.start, from), minInt(m.end, to)
+		b.WriteString(html.EscapeString(string(runes[pos:start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[start:end])))
+		b.WriteString("</mark>")
+		pos = end
+	}
+
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func matchesTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
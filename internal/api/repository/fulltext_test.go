@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFulltextTerms(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"lowercases", "Hello World", []string{"hello", "world"}},
+		{"punctuation separates", "snake_case, kebab-case.", []string{"snake", "case", "kebab", "case"}},
+		{"boolean operators", `+go -java "exact phrase" wild* ~near <less >more (group) @1`, []string{"go", "java", "exact", "phrase", "wild", "near", "less", "more", "group", "1"}},
+		{"letters of any script", "Ünïcode 日本 2023", []string{"ünïcode", "日本", "2023"}},
+		{"only separators", "*** --- !!!", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fulltextTerms(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fulltextTerms(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	long := strings.Repeat("a ", 100) + "needle" + strings.Repeat(" b", 200)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"no match", "short text", []string{"zzz"}, "short text"},
+		{"no terms", "short text", nil, "short text"},
+		{"whole word", "find the needle here", []string{"needle"}, "find the <mark>needle</mark> here"},
+		{"prefix", "Generics and generic code", []string{"gener"}, "<mark>Generics</mark> and <mark>generic</mark> code"},
+		{"several terms", "red green blue", []string{"red", "blue"}, "<mark>red</mark> green <mark>blue</mark>"},
+		{"term inside a word", "unhappy", []string{"happy"}, "unhappy"},
+		{"escapes html", `<b>bold</b> & "quoted"`, []string{"bold"}, `&lt;b&gt;<mark>bold</mark>&lt;/b&gt; &amp; &#34;quoted&#34;`},
+		{
+			"window around the first match",
+			long,
+			[]string{"needle"},
+			"…" + strings.Repeat("a ", 25) + "<mark>needle</mark>" + strings.Repeat(" b", 72) + "…",
+		},
+		{
+			"window from the start",
+			strings.Repeat("x", 250),
+			[]string{"zzz"},
+			strings.Repeat("x", 200) + "…",
+		},
+		{"multibyte runes", "héllo wörld", []string{"wör"}, "héllo <mark>wörld</mark>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightSnippet(tt.text, tt.terms); got != tt.want {
+				t.Errorf("highlightSnippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
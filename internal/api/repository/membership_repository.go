@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+// MembershipRepository holds the ways into a forum that is not public: join
+// requests and invites
+type MembershipRepository interface {
+	WithTx(tx *gorm.DB) MembershipRepository
+	CreateJoinRequest(joinRequest *models.JoinRequest) error
+	GetPendingJoinRequest(forumID uint, userID uint) (*models.JoinRequest, error)
+	GetJoinRequestByID(id uint) (*models.JoinRequest, error)
+	ListPendingJoinRequests(forumID uint, pagination *request.ReqPagination) ([]response.ResJoinRequest, *response.ResPagination, error)
+	ReviewJoinRequest(joinRequest *models.JoinRequest, status string, userID uint) error
+	CreateInvite(invite *models.ForumInvite) error
+	GetInviteByCodeHash(codeHash string) (*models.ForumInvite, error)
+	// UseInvite counts a use of the invite, failing when it expired or ran
+	// out of uses
+	UseInvite(invite *models.ForumInvite) error
+}
+
+type membershipRepository struct {
+	db *database.Database
+}
+
+func NewMembershipRepository(db *database.Database) MembershipRepository {
+	return &membershipRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *membershipRepository) WithTx(tx *gorm.DB) MembershipRepository {
+	return &membershipRepository{txDatabase(tx)}
+}
+
+var joinRequestPageSource = pageSource{
+	table: "join_requests jr",
+	id:    "jr.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+	},
+	defaultSort: request.SortOldest,
+}
+
+func (r *membershipRepository) CreateJoinRequest(joinRequest *models.JoinRequest) error {
+	return r.db.DB.Create(joinRequest).Error
+}
+
+func (r *membershipRepository) GetPendingJoinRequest(forumID uint, userID uint) (*models.JoinRequest, error) {
+	var joinRequest models.JoinRequest
+	err := r.db.DB.
+		Where("forum_id = ?", forumID).
+		Where("user_id = ?", userID).
+		Where("status = ?", constants.JoinRequestPending).
+		First(&joinRequest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &joinRequest, nil
+}
+
+func (r *membershipRepository) GetJoinRequestByID(id uint) (*models.JoinRequest, error) {
+	var joinRequest models.JoinRequest
+	err := r.db.DB.Where("id = ?", id).First(&joinRequest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &joinRequest, nil
+}
+
+func (r *membershipRepository) ListPendingJoinRequests(forumID uint, pagination *request.ReqPagination) ([]response.ResJoinRequest, *response.ResPagination, error) {
+	page, err := newPage(joinRequestPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("jr.id ASC")
+
+	query := `
+		SELECT jr.id, jr.user_id, u.name AS user_name, u.nim, u.profile_image, jr.message, jr.created_at
+		FROM join_requests jr
+		INNER JOIN users u ON u.id = jr.user_id
+		WHERE jr.forum_id = ?
+		AND jr.status = ?
+		AND jr.deleted_at IS NULL
+	` + cursorWhere + orderBy
+
+	args := append(append([]interface{}{forumID, constants.JoinRequestPending}, cursorArgs...), orderArgs...)
+
+	var res []response.ResJoinRequest
+	if err := r.db.DB.Raw(query, args...).Scan(&res).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, res, func(jr response.ResJoinRequest) uint { return jr.ID })
+}
+
+func (r *membershipRepository) ReviewJoinRequest(joinRequest *models.JoinRequest, status string, userID uint) error {
+	return r.db.DB.Model(&joinRequest).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": userID,
+		"reviewed_at": time.Now(),
+	}).Error
+}
+
+func (r *membershipRepository) CreateInvite(invite *models.ForumInvite) error {
+	return r.db.DB.Create(invite).Error
+}
+
+func (r *membershipRepository) GetInviteByCodeHash(codeHash string) (*models.ForumInvite, error) {
+	var invite models.ForumInvite
+	err := r.db.DB.Where("code_hash = ?", codeHash).First(&invite).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+func (r *membershipRepository) UseInvite(invite *models.ForumInvite) error {
+	// Checking the limits in the update itself keeps concurrent joins from
+	// using the invite more than MaxUses times
+	result := r.db.DB.
+		Model(&models.ForumInvite{}).
+		Where("id = ?", invite.ID).
+		Where("max_uses = 0 OR uses < max_uses").
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf(helper.InviteInvalid)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+type MentionRepository interface {
+	WithTx(tx *gorm.DB) MentionRepository
+	// FindMembersByHandles returns the members of the forum whose NIM is one
+	// of nims or whose normalized name is one of names
+	FindMembersByHandles(forumID uint, nims []string, names []string) ([]models.User, error)
+	// ReplaceMentions sets the users a post mentions and returns the ones
+	// it did not mention before
+	ReplaceMentions(threadID uint, replyID *uint, mentionedBy uint, userIDs []uint) ([]uint, error)
+	ListMentionedThreads(userID uint, pagination *request.ReqPagination) ([]response.ResMentionedThread, *response.ResPagination, error)
+}
+
+type mentionRepository struct {
+	db *database.Database
+}
+
+func NewMentionRepository(db *database.Database) MentionRepository {
+	return &mentionRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *mentionRepository) WithTx(tx *gorm.DB) MentionRepository {
+	return &mentionRepository{txDatabase(tx)}
+}
+
+// mentionedThreadPageSource pages the threads a user was mentioned in by
+// their latest mention
+var mentionedThreadPageSource = pageSource{
+	table: "mentions m",
+	id:    "m.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+	},
+}
+
+// normalizedNameSQL matches the way names are written in mentions, which
+// can't contain spaces
+const normalizedNameSQL = "REPLACE(REPLACE(REPLACE(LOWER(u.name), ' ', ''), '.', ''), '_', '')"
+
+func (r *mentionRepository) FindMembersByHandles(forumID uint, nims []string, names []string) ([]models.User, error) {
+	var users []models.User
+
+	if len(nims) == 0 && len(names) == 0 {
+		return users, nil
+	}
+
+	err := r.db.DB.
+		Table("users u").
+		Select("u.*").
+		Joins("INNER JOIN user_forums uf ON uf.user_id = u.id AND uf.forum_id = ? AND uf.is_removed = 0 AND uf.left_at IS NULL AND uf.deleted_at IS NULL", forumID).
+		Where("u.deleted_at IS NULL").
+		Where("u.nim IN ? OR "+normalizedNameSQL+" IN ?", nims, names).
+		Find(&users).Error
+
+	return users, err
+}
+
+func (r *mentionRepository) ReplaceMentions(threadID uint, replyID *uint, mentionedBy uint, userIDs []uint) ([]uint, error) {
+	query := r.db.DB.Where("thread_id = ?", threadID)
+	if replyID == nil {
+		query = query.Where("reply_id IS NULL")
+	} else {
+		query = query.Where("reply_id = ?", *replyID)
+	}
+
+	var existing []models.Mention
+	if err := query.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	keep := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		keep[id] = true
+	}
+
+	// Forget the users the post no longer mentions
+	mentioned := make(map[uint]bool, len(existing))
+	var removed []uint
+	for _, mention := range existing {
+		mentioned[mention.UserID] = true
+		if !keep[mention.UserID] {
+			removed = append(removed, mention.ID)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := r.db.DB.Delete(&models.Mention{}, removed).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var added []uint
+	var mentions []models.Mention
+	for _, id := range userIDs {
+		if mentioned[id] {
+			continue
+		}
+
+		added = append(added, id)
+		mentions = append(mentions, models.Mention{
+			UserID:      id,
+			ThreadID:    threadID,
+			ReplyID:     replyID,
+			MentionedBy: mentionedBy,
+		})
+	}
+
+	if len(mentions) > 0 {
+		if err := r.db.DB.Create(&mentions).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return added, nil
+}
+
+func (r *mentionRepository) ListMentionedThreads(userID uint, pagination *request.ReqPagination) ([]response.ResMentionedThread, *response.ResPagination, error) {
+	page, err := newPage(mentionedThreadPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("m.id DESC")
+
+	// m holds the latest mention of the user in each thread, leaving out
+	// mentions in deleted replies
+	query := `
+		SELECT t.*, f.forum_name, f.forum_image, m.id AS mention_id, lm.created_at AS mentioned_at
+		FROM (
+			SELECT mn.thread_id, MAX(mn.id) AS id
+			FROM mentions mn
+			LEFT JOIN replies r ON r.id = mn.reply_id
+			WHERE mn.user_id = ?
+			AND (mn.reply_id IS NULL OR r.deleted_at IS NULL)
+			GROUP BY mn.thread_id
+		) m
+		INNER JOIN mentions lm ON lm.id = m.id
+		INNER JOIN threads t ON t.id = m.thread_id
+		INNER JOIN forums f ON f.id = t.forum_id
+		WHERE t.deleted_at IS NULL
+		AND ` + readableForumSQL("f") + cursorWhere + orderBy
+
+	args := append(append([]interface{}{userID, userID}, cursorArgs...), orderArgs...)
+
+	var res []response.ResMentionedThread
+	if err := r.db.DB.Raw(query, args...).Scan(&res).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, res, func(t response.ResMentionedThread) uint { return t.MentionID })
+}
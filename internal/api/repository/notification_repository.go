@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	WithTx(tx *gorm.DB) NotificationRepository
+	CreateNotification(notification *models.Notification) error
+	ListNotifications(userID uint, unreadOnly bool, pagination *request.ReqPagination) ([]models.Notification, *response.ResPagination, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID uint, notificationIDs []uint) error
+	MarkAllRead(userID uint) error
+}
+
+type notificationRepository struct {
+	db *database.Database
+}
+
+func NewNotificationRepository(db *database.Database) NotificationRepository {
+	return &notificationRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *notificationRepository) WithTx(tx *gorm.DB) NotificationRepository {
+	return &notificationRepository{txDatabase(tx)}
+}
+
+var notificationPageSource = pageSource{
+	table: "notifications n",
+	id:    "n.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+	},
+}
+
+func (r *notificationRepository) CreateNotification(notification *models.Notification) error {
+	return r.db.DB.Create(notification).Error
+}
+
+func (r *notificationRepository) ListNotifications(userID uint, unreadOnly bool, pagination *request.ReqPagination) ([]models.Notification, *response.ResPagination, error) {
+	page, err := newPage(notificationPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var notifications []models.Notification
+
+	query := r.db.DB.
+		Table("notifications n").
+		Where("n.user_id = ?", userID).
+		Where("n.deleted_at IS NULL")
+
+	if unreadOnly {
+		query = query.Where("n.read_at IS NULL")
+	}
+
+	err = page.apply(query, "n.id DESC").Scan(&notifications).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, notifications, func(n models.Notification) uint { return n.ID })
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+
+	err := r.db.DB.
+		Model(&models.Notification{}).
+		Where("user_id = ?", userID).
+		Where("read_at IS NULL").
+		Count(&count).Error
+
+	return count, err
+}
+
+// MarkRead marks the given notifications of the user as read. Ids of other
+// users' notifications are ignored.
+func (r *notificationRepository) MarkRead(userID uint, notificationIDs []uint) error {
+	return r.db.DB.
+		Model(&models.Notification{}).
+		Where("user_id = ?", userID).
+		Where("id IN ?", notificationIDs).
+		Where("read_at IS NULL").
+		Update("read_at", time.Now()).Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint) error {
+	return r.db.DB.
+		Model(&models.Notification{}).
+		Where("user_id = ?", userID).
+		Where("read_at IS NULL").
+		Update("read_at", time.Now()).Error
+}
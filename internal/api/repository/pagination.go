@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	// repliesPerBranch is how many children of a reply are loaded along
+	// with it in a reply tree
+	repliesPerBranch = 5
+)
+
+// sortKey is an integer SQL expression a list is ordered by, descending.
+// args are bound to the placeholders of expr every time it is used.
+type sortKey struct {
+	expr string
+	args []interface{}
+}
+
+// pageSource describes a paginated table: how it is aliased in the list
+// queries, its id column and the sort keys of every supported sort option.
+// A sort option without keys orders the list by id only.
+type pageSource struct {
+	table       string
+	id          string
+	sorts       map[string][]sortKey
+	defaultSort string
+}
+
+var forumMembersKey = sortKey{expr: "(SELECT COUNT(*) FROM user_forums pm WHERE pm.forum_id = f.id AND pm.is_removed = 0 AND pm.left_at IS NULL AND pm.deleted_at IS NULL)"}
+
+var forumPageSource = pageSource{
+	table: "forums f",
+	id:    "f.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+		request.SortTop:    {forumMembersKey},
+	},
+}
+
+var threadPageSource = pageSource{
+	table: "threads t",
+	id:    "t.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest:      nil,
+		request.SortOldest:      nil,
+		request.SortTop:         {{expr: "(t.number_of_upvotes - t.number_of_downvotes)"}},
+		request.SortMostReplied: {{expr: "(SELECT COUNT(*) FROM replies pr WHERE pr.thread_id = t.id AND pr.deleted_at IS NULL)"}},
+	},
+}
+
+var replyPageSource = pageSource{
+	table: "replies r",
+	id:    "r.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+		request.SortTop:    {{expr: "(r.number_of_upvotes - r.number_of_downvotes)"}},
+	},
+}
+
+// threadReplyPageSource pages the replies of a single thread, which read
+// as a conversation and so default to the oldest first
+var threadReplyPageSource = pageSource{
+	table:       replyPageSource.table,
+	id:          replyPageSource.id,
+	sorts:       replyPageSource.sorts,
+	defaultSort: request.SortOldest,
+}
+
+// pageCursor is the decoded form of the opaque cursor handed to clients. It
+// points at the last item of the previous page.
+type pageCursor struct {
+	Sort   string  `json:"s"`
+	Values []int64 `json:"v,omitempty"`
+	ID     uint    `json:"id,omitempty"`
+	Offset int     `json:"o,omitempty"`
+}
+
+// page is a keyset pagination clause ready to be added to a list query
+type page struct {
+	source pageSource
+	sort   string
+	limit  int
+	cond   string
+	args   []interface{}
+}
+
+// newPage validates the pagination params against the source. A nil req
+// means the list is not paginated at all, which is only meant for internal
+// callers.
+func newPage(source pageSource, req *request.ReqPagination) (*page, error) {
+	if req == nil {
+		return nil, nil
+	}
+
+	p := &page{source: source, sort: req.Sort, limit: req.Limit}
+
+	if p.sort == "" {
+		p.sort = source.defaultSort
+	}
+
+	if p.sort == "" {
+		p.sort = request.SortNewest
+	}
+
+	keys, ok := source.sorts[p.sort]
+	if !ok {
+		return nil, fmt.Errorf(helper.SortNotSupported)
+	}
+
+	if p.limit <= 0 {
+		p.limit = defaultPageLimit
+	}
+
+	if p.limit > maxPageLimit {
+		p.limit = maxPageLimit
+	}
+
+	if req.Cursor == "" {
+		return p, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf(helper.InvalidCursor)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != p.sort || len(cursor.Values) != len(keys) {
+		return nil, fmt.Errorf(helper.InvalidCursor)
+	}
+
+	if len(keys) == 0 && p.sort == request.SortOldest {
+		p.cond = source.id + " > ?"
+		p.args = []interface{}{cursor.ID}
+		return p, nil
+	}
+
+	// (k1 < v1) OR (k1 = v1 AND k2 < v2) OR ... OR (k1 = v1 AND ... AND id < id)
+	var ors []string
+	var equal []string
+	var equalArgs []interface{}
+
+	for i, key := range keys {
+		ors = append(ors, andCond(equal, key.expr+" < ?"))
+		p.args = append(p.args, equalArgs...)
+		p.args = append(p.args, key.args...)
+		p.args = append(p.args, cursor.Values[i])
+
+		equal = append(equal, key.expr+" = ?")
+		equalArgs = append(equalArgs, key.args...)
+		equalArgs = append(equalArgs, cursor.Values[i])
+	}
+
+	ors = append(ors, andCond(equal, source.id+" < ?"))
+	p.args = append(p.args, equalArgs...)
+	p.args = append(p.args, cursor.ID)
+	p.cond = "(" + strings.Join(ors, " OR ") + ")"
+
+	return p, nil
+}
+
+func andCond(conds []string, last string) string {
+	all := make([]string, 0, len(conds)+1)
+	all = append(all, conds...)
+
+	return "(" + strings.Join(append(all, last), " AND ") + ")"
+}
+
+// where returns the keyset condition prefixed with AND, to be appended to a
+// raw query that already has a WHERE clause
+func (p *page) where() (string, []interface{}) {
+	if p == nil || p.cond == "" {
+		return "", nil
+	}
+
+	return " AND " + p.cond, p.args
+}
+
+// orderLimit returns the ORDER BY and LIMIT clauses of a raw query along
+// with the args of the sort keys. One extra row is fetched to tell whether
+// there is a next page.
+func (p *page) orderLimit(fallback string) (string, []interface{}) {
+	if p == nil {
+		return " ORDER BY " + fallback, nil
+	}
+
+	order, args := p.order()
+
+	return fmt.Sprintf(" ORDER BY %s LIMIT %d", order, p.limit+1), args
+}
+
+func (p *page) order() (string, []interface{}) {
+	keys := p.source.sorts[p.sort]
+
+	if len(keys) == 0 && p.sort == request.SortOldest {
+		return p.source.id + " ASC", nil
+	}
+
+	var order []string
+	var args []interface{}
+
+	for _, key := range keys {
+		order = append(order, key.expr+" DESC")
+		args = append(args, key.args...)
+	}
+
+	return strings.Join(append(order, p.source.id+" DESC"), ", "), args
+}
+
+// apply adds the keyset condition, order and limit to a query builder
+func (p *page) apply(query *gorm.DB, fallback string) *gorm.DB {
+	if p == nil {
+		return query.Order(fallback)
+	}
+
+	if p.cond != "" {
+		query = query.Where(p.cond, p.args...)
+	}
+
+	order, args := p.order()
+
+	return query.
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: order, Vars: args, WithoutParentheses: true}}).
+		Limit(p.limit + 1)
+}
+
+// paginate trims the extra row fetched by orderLimit/apply and builds the
+// pagination envelope, looking up the sort values of the last item for the
+// next cursor. id returns the id of an item.
+func paginate[T any](db *gorm.DB, p *page, items []T, id func(item T) uint) ([]T, *response.ResPagination, error) {
+	if p == nil {
+		return items, nil, nil
+	}
+
+	res := &response.ResPagination{Limit: p.limit, Sort: p.sort}
+
+	if len(items) <= p.limit {
+		return items, res, nil
+	}
+
+	items = items[:p.limit]
+	cursor := pageCursor{Sort: p.sort, ID: id(items[len(items)-1])}
+
+	if keys := p.source.sorts[p.sort]; len(keys) > 0 {
+		var exprs []string
+		var args []interface{}
+
+		for _, key := range keys {
+			exprs = append(exprs, key.expr)
+			args = append(args, key.args...)
+		}
+
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", strings.Join(exprs, ", "), p.source.table, p.source.id)
+
+		rows, err := db.Raw(query, append(args, cursor.ID)...).Rows()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rows.Close()
+
+		if !rows.Next() {
+			return nil, nil, fmt.Errorf(helper.InvalidCursor)
+		}
+
+		cursor.Values = make([]int64, len(keys))
+		dest := make([]interface{}, len(keys))
+		for i := range cursor.Values {
+			dest[i] = &cursor.Values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res.NextCursor = encodeCursor(cursor)
+	res.HasMore = true
+
+	return items, res, nil
+}
+
+func encodeCursor(cursor pageCursor) string {
+	// a cursor only holds strings and numbers, so it always marshals
+	raw, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// offsetPage paginates lists whose order can not be expressed as a keyset,
+// like search hits ranked by a score computed at query time. Its cursor
+// carries the offset of the next page instead of the last item.
+type offsetPage struct {
+	sort   string
+	limit  int
+	offset int
+}
+
+// newOffsetPage validates the pagination params against the supported sort
+// options, the first one being the default
+func newOffsetPage(req *request.ReqPagination, sorts ...string) (*offsetPage, error) {
+	p := &offsetPage{sort: req.Sort, limit: req.Limit}
+
+	if p.sort == "" {
+		p.sort = sorts[0]
+	}
+
+	supported := false
+	for _, sort := range sorts {
+		supported = supported || sort == p.sort
+	}
+
+	if !supported {
+		return nil, fmt.Errorf(helper.SortNotSupported)
+	}
+
+	if p.limit <= 0 {
+		p.limit = defaultPageLimit
+	}
+
+	if p.limit > maxPageLimit {
+		p.limit = maxPageLimit
+	}
+
+	if req.Cursor == "" {
+		return p, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf(helper.InvalidCursor)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != p.sort || cursor.Offset < 0 {
+		return nil, fmt.Errorf(helper.InvalidCursor)
+	}
+
+	p.offset = cursor.Offset
+
+	return p, nil
+}
+
+// paginateOffset trims the extra item fetched past the limit and builds the
+// pagination envelope
+func paginateOffset[T any](p *offsetPage, items []T) ([]T, *response.ResPagination, error) {
+	res := &response.ResPagination{Limit: p.limit, Sort: p.sort}
+
+	if len(items) <= p.limit {
+		return items, res, nil
+	}
+
+	res.NextCursor = encodeCursor(pageCursor{Sort: p.sort, Offset: p.offset + p.limit})
+	res.HasMore = true
+
+	return items[:p.limit], res, nil
+}
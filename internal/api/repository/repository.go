@@ -11,6 +11,14 @@ var Module = fx.Module("repository",
 		NewUserRepository,
 		NewForumRepository,
 		NewThreadRepository,
+		NewSessionRepository,
+		NewAdminRepository,
+		NewNotificationRepository,
+		NewAttachmentRepository,
+		NewMentionRepository,
+		NewBanRepository,
+		NewMembershipRepository,
+		NewSearchIndex,
 		NewGormTransactionRepository,
 	),
 )
@@ -19,6 +27,10 @@ type TransactionRepository interface {
 	BeginTransaction() *gorm.DB
 	CommitTransaction(tx *gorm.DB) error
 	RollbackTransaction(tx *gorm.DB) error
+	// WithTransaction runs fn inside a transaction. The transaction is
+	// committed when fn returns nil and rolled back when fn returns an
+	// error or panics.
+	WithTransaction(fn func(tx *gorm.DB) error) error
 }
 
 type gormTransactionRepository struct {
@@ -41,3 +53,13 @@ func (r *gormTransactionRepository) CommitTransaction(tx *gorm.DB) error {
 func (r *gormTransactionRepository) RollbackTransaction(tx *gorm.DB) error {
 	return tx.Rollback().Error
 }
+
+func (r *gormTransactionRepository) WithTransaction(fn func(tx *gorm.DB) error) error {
+	return r.db.DB.Transaction(fn)
+}
+
+// txDatabase wraps a transaction so it can be handed to the repository
+// constructors in place of the shared connection.
+func txDatabase(tx *gorm.DB) *database.Database {
+	return &database.Database{DB: tx}
+}
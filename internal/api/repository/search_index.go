@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+// SearchDocument is a thread or a reply as seen by the search index. A
+// thread has a zero ReplyID.
+type SearchDocument struct {
+	ForumID     uint
+	ForumName   string
+	ThreadID    uint
+	ThreadTitle string
+	ReplyID     uint
+	Text        string
+	CreatedAt   time.Time
+}
+
+// SearchIndex searches thread titles, thread text and reply text. The index
+// is told about every write so implementations that keep their own copy of
+// the posts stay in sync; the MySQL one reads the tables directly and
+// ignores them.
+type SearchIndex interface {
+	Index(doc *SearchDocument) error
+	RenameForum(forumID uint, forumName string) error
+	RemoveForum(forumID uint) error
+	RemoveThread(threadID uint) error
+	RemoveReply(replyID uint) error
+	// Search returns the hits of req, limited to the given forums
+	Search(req *request.ReqSearch, forumIDs []uint) ([]response.ResSearchHit, *response.ResPagination, error)
+}
+
+// NewSearchIndex returns the index selected by SEARCH_DRIVER. "memory"
+// keeps an in-process index loaded from the database at startup, anything
+// else uses the MySQL FULLTEXT indexes.
+func NewSearchIndex(db *database.Database, env *lib.Env) (SearchIndex, error) {
+	if env.SearchDriver == "memory" {
+		index := NewMemorySearchIndex()
+
+		docs, err := listSearchDocuments(db.DB, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range docs {
+			if err := index.Index(&docs[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		return index, nil
+	}
+
+	return NewMySQLSearchIndex(db), nil
+}
+
+// listSearchDocuments loads the live threads and replies as documents, only
+// the ones of the forum unless forumID is zero
+func listSearchDocuments(db *gorm.DB, forumID uint) ([]SearchDocument, error) {
+	forumWhere := ""
+	args := []interface{}{}
+	if forumID != 0 {
+		forumWhere = "AND t.forum_id = ?"
+		args = append(args, forumID, forumID)
+	}
+
+	query := `
+		SELECT t.forum_id, f.forum_name, t.id AS thread_id, t.title AS thread_title, 0 AS reply_id, t.text, t.created_at
+		FROM threads t
+		INNER JOIN forums f ON f.id = t.forum_id
+		WHERE t.deleted_at IS NULL
+		AND f.deleted_at IS NULL
+		` + forumWhere + `
+		UNION ALL
+		SELECT t.forum_id, f.forum_name, t.id, t.title, r.id, r.text, r.created_at
+		FROM replies r
+		INNER JOIN threads t ON t.id = r.thread_id
+		INNER JOIN forums f ON f.id = t.forum_id
+		WHERE r.deleted_at IS NULL
+		AND t.deleted_at IS NULL
+		AND f.deleted_at IS NULL
+		` + forumWhere
+
+	var docs []SearchDocument
+	if err := db.Raw(query, args...).Scan(&docs).Error; err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+type mysqlSearchIndex struct {
+	db *database.Database
+}
+
+func NewMySQLSearchIndex(db *database.Database) SearchIndex {
+	return &mysqlSearchIndex{db}
+}
+
+func (i *mysqlSearchIndex) Index(doc *SearchDocument) error {
+	return nil
+}
+
+func (i *mysqlSearchIndex) RenameForum(forumID uint, forumName string) error {
+	return nil
+}
+
+func (i *mysqlSearchIndex) RemoveForum(forumID uint) error {
+	return nil
+}
+
+func (i *mysqlSearchIndex) RemoveThread(threadID uint) error {
+	return nil
+}
+
+func (i *mysqlSearchIndex) RemoveReply(replyID uint) error {
+	return nil
+}
+
+func (i *mysqlSearchIndex) Search(req *request.ReqSearch, forumIDs []uint) ([]response.ResSearchHit, *response.ResPagination, error) {
+	page, err := newOffsetPage(&req.ReqPagination, request.SortRelevance, request.SortNewest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	terms := fulltextTerms(req.Query)
+	if len(terms) == 0 || len(forumIDs) == 0 {
+		return paginateOffset(page, []response.ResSearchHit{})
+	}
+
+	against := booleanPrefixQuery(terms)
+
+	order := "score DESC, created_at DESC"
+	if page.sort == request.SortNewest {
+		order = "created_at DESC"
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT 'thread' AS type, f.id AS forum_id, f.forum_name, t.id AS thread_id, t.title AS thread_title, NULL AS reply_id, t.text,
+				MATCH(t.title, t.text) AGAINST (? IN BOOLEAN MODE) AS score, t.created_at
+			FROM threads t
+			INNER JOIN forums f ON f.id = t.forum_id
+			WHERE t.forum_id IN ?
+			AND t.deleted_at IS NULL
+			AND f.deleted_at IS NULL
+			AND MATCH(t.title, t.text) AGAINST (? IN BOOLEAN MODE)
+			UNION ALL
+			SELECT 'reply', f.id, f.forum_name, t.id, t.title, r.id, r.text,
+				MATCH(r.text) AGAINST (? IN BOOLEAN MODE), r.created_at
+			FROM replies r
+			INNER JOIN threads t ON t.id = r.thread_id
+			INNER JOIN forums f ON f.id = t.forum_id
+			WHERE t.forum_id IN ?
+			AND r.deleted_at IS NULL
+			AND t.deleted_at IS NULL
+			AND f.deleted_at IS NULL
+			AND MATCH(r.text) AGAINST (? IN BOOLEAN MODE)
+		) AS hits
+		ORDER BY ` + order + `
+		LIMIT ? OFFSET ?
+	`
+
+	var rows []struct {
+		response.ResSearchHit
+		Text string
+	}
+
+	err = i.db.DB.Raw(query,
+		against, forumIDs, against,
+		against, forumIDs, against,
+		page.limit+1, page.offset,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hits := make([]response.ResSearchHit, 0, len(rows))
+	for _, row := range rows {
+		hit := row.ResSearchHit
+		hit.Snippet = highlightSnippet(row.Text, terms)
+		hits = append(hits, hit)
+	}
+
+	return paginateOffset(page, hits)
+}
+
+// searchHitType tells whether a document is a thread or a reply
+func searchHitType(doc *SearchDocument) string {
+	if doc.ReplyID == 0 {
+		return constants.SearchHitThread
+	}
+
+	return constants.SearchHitReply
+}
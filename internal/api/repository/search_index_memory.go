@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+)
+
+// titleWeight is how much more a word of a thread title counts than a word
+// of the text
+const titleWeight = 2
+
+type memoryDocument struct {
+	SearchDocument
+	words []string
+}
+
+type memorySearchIndex struct {
+	mu   sync.RWMutex
+	docs map[memoryDocumentKey]*memoryDocument
+}
+
+type memoryDocumentKey struct {
+	threadID uint
+	replyID  uint
+}
+
+// NewMemorySearchIndex returns an empty in-process index. It needs no
+// database, which also makes it usable from tests.
+func NewMemorySearchIndex() SearchIndex {
+	return &memorySearchIndex{docs: map[memoryDocumentKey]*memoryDocument{}}
+}
+
+func (i *memorySearchIndex) Index(doc *SearchDocument) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.docs[memoryDocumentKey{doc.ThreadID, doc.ReplyID}] = &memoryDocument{
+		SearchDocument: *doc,
+		words:          fulltextTerms(doc.Text),
+	}
+
+	// the replies of an edited thread show its new title
+	if doc.ReplyID == 0 {
+		for _, d := range i.docs {
+			if d.ThreadID == doc.ThreadID {
+				d.ThreadTitle = doc.ThreadTitle
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *memorySearchIndex) RenameForum(forumID uint, forumName string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, d := range i.docs {
+		if d.ForumID == forumID {
+			d.ForumName = forumName
+		}
+	}
+
+	return nil
+}
+
+func (i *memorySearchIndex) RemoveForum(forumID uint) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for key, d := range i.docs {
+		if d.ForumID == forumID {
+			delete(i.docs, key)
+		}
+	}
+
+	return nil
+}
+
+func (i *memorySearchIndex) RemoveThread(threadID uint) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for key := range i.docs {
+		if key.threadID == threadID {
+			delete(i.docs, key)
+		}
+	}
+
+	return nil
+}
+
+func (i *memorySearchIndex) RemoveReply(replyID uint) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for key := range i.docs {
+		if key.replyID == replyID {
+			delete(i.docs, key)
+		}
+	}
+
+	return nil
+}
+
+func (i *memorySearchIndex) Search(req *request.ReqSearch, forumIDs []uint) ([]response.ResSearchHit, *response.ResPagination, error) {
+	page, err := newOffsetPage(&req.ReqPagination, request.SortRelevance, request.SortNewest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	terms := fulltextTerms(req.Query)
+
+	visible := make(map[uint]bool, len(forumIDs))
+	for _, id := range forumIDs {
+		visible[id] = true
+	}
+
+	hits := []response.ResSearchHit{}
+
+	i.mu.RLock()
+	for _, d := range i.docs {
+		if !visible[d.ForumID] {
+			continue
+		}
+
+		score := scoreWords(d.words, terms)
+		if d.ReplyID == 0 {
+			score += titleWeight * scoreWords(fulltextTerms(d.ThreadTitle), terms)
+		}
+
+		if score == 0 {
+			continue
+		}
+
+		hit := response.ResSearchHit{
+			Type:        searchHitType(&d.SearchDocument),
+			ForumID:     d.ForumID,
+			ForumName:   d.ForumName,
+			ThreadID:    d.ThreadID,
+			ThreadTitle: d.ThreadTitle,
+			Snippet:     highlightSnippet(d.Text, terms),
+			Score:       score,
+			CreatedAt:   d.CreatedAt,
+		}
+
+		if d.ReplyID != 0 {
+			replyID := d.ReplyID
+			hit.ReplyID = &replyID
+		}
+
+		hits = append(hits, hit)
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(hits, func(a, b int) bool {
+		if page.sort == request.SortRelevance && hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+
+		return hits[a].CreatedAt.After(hits[b].CreatedAt)
+	})
+
+	if page.offset >= len(hits) {
+		return paginateOffset(page, []response.ResSearchHit{})
+	}
+
+	return paginateOffset(page, hits[page.offset:])
+}
+
+// scoreWords scores words against the search terms, counting prefix matches
+// with a logarithmic term frequency so a long post repeating a word does not
+// drown out shorter ones
+func scoreWords(words []string, terms []string) float64 {
+	var score float64
+
+	for _, term := range terms {
+		count := 0
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				count++
+			}
+		}
+
+		if count > 0 {
+			score += 1 + math.Log(float64(count))
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+)
+
+var searchTestTime = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestSearchIndex indexes two forums:
+//
+//	thread 1 (forum 1): "Generics" in the title and nowhere in the text
+//	reply 10 (thread 1): "Generics" once
+//	thread 2 (forum 1): "generics" twice in the text
+//	thread 3 (forum 2): "Generics" in the title and the text
+func newTestSearchIndex(t *testing.T) SearchIndex {
+	t.Helper()
+
+	index := NewMemorySearchIndex()
+
+	docs := []SearchDocument{
+		{ForumID: 1, ForumName: "Go", ThreadID: 1, ThreadTitle: "Generics in Go", Text: "A look at type parameters", CreatedAt: searchTestTime},
+		{ForumID: 1, ForumName: "Go", ThreadID: 1, ThreadTitle: "Generics in Go", ReplyID: 10, Text: "Generics made my code shorter", CreatedAt: searchTestTime.Add(time.Hour)},
+		{ForumID: 1, ForumName: "Go", ThreadID: 2, ThreadTitle: "Interfaces", Text: "Generics or interfaces? generics win", CreatedAt: searchTestTime.Add(2 * time.Hour)},
+		{ForumID: 2, ForumName: "Private", ThreadID: 3, ThreadTitle: "Generics", Text: "generics", CreatedAt: searchTestTime.Add(3 * time.Hour)},
+	}
+
+	for i := range docs {
+		if err := index.Index(&docs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return index
+}
+
+// hitKeys identifies hits as thread ids, with replies as their reply id
+func hitKeys(hits []response.ResSearchHit) []uint {
+	keys := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		if hit.ReplyID != nil {
+			keys = append(keys, *hit.ReplyID)
+		} else {
+			keys = append(keys, hit.ThreadID)
+		}
+	}
+
+	return keys
+}
+
+func equalKeys(a []uint, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestMemorySearchIndexVisibility(t *testing.T) {
+	index := newTestSearchIndex(t)
+
+	tests := []struct {
+		name     string
+		forumIDs []uint
+		want     []uint
+	}{
+		{"no readable forums", nil, []uint{}},
+		{"one readable forum", []uint{1}, []uint{1, 2, 10}},
+		{"every forum", []uint{1, 2}, []uint{3, 1, 2, 10}},
+		{"unknown forum", []uint{99}, []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, _, err := index.Search(&request.ReqSearch{Query: "generics"}, tt.forumIDs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := hitKeys(hits); !equalKeys(got, tt.want) {
+				t.Errorf("hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySearchIndexRanking(t *testing.T) {
+	index := newTestSearchIndex(t)
+
+	tests := []struct {
+		name  string
+		query string
+		sort  string
+		want  []uint
+	}{
+		// A title word counts double, a word repeated in the text counts
+		// less than that
+		{"relevance", "generics", request.SortRelevance, []uint{1, 2, 10}},
+		{"relevance by default", "generics", "", []uint{1, 2, 10}},
+		{"newest", "generics", request.SortNewest, []uint{2, 10, 1}},
+		{"prefix match", "gener", request.SortRelevance, []uint{1, 2, 10}},
+		{"several terms", "interfaces generics", request.SortRelevance, []uint{2, 1, 10}},
+		// Equal scores fall back to the newest first
+		{"ties", "look made", request.SortRelevance, []uint{10, 1}},
+		{"no match", "rust", request.SortRelevance, []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &request.ReqSearch{Query: tt.query}
+			req.Sort = tt.sort
+
+			hits, _, err := index.Search(req, []uint{1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := hitKeys(hits); !equalKeys(got, tt.want) {
+				t.Errorf("hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySearchIndexHit(t *testing.T) {
+	index := newTestSearchIndex(t)
+
+	hits, _, err := index.Search(&request.ReqSearch{Query: "shorter"}, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+
+	hit := hits[0]
+	if hit.Type != constants.SearchHitReply || hit.ReplyID == nil || *hit.ReplyID != 10 || hit.ThreadID != 1 || hit.ThreadTitle != "Generics in Go" || hit.ForumName != "Go" {
+		t.Errorf("unexpected hit %+v", hit)
+	}
+
+	if want := "Generics made my code <mark>shorter</mark>"; hit.Snippet != want {
+		t.Errorf("snippet = %q, want %q", hit.Snippet, want)
+	}
+}
+
+func TestMemorySearchIndexPagination(t *testing.T) {
+	index := newTestSearchIndex(t)
+
+	req := &request.ReqSearch{Query: "generics"}
+	req.Limit = 2
+
+	first, pagination, err := index.Search(req, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hitKeys(first); !equalKeys(got, []uint{1, 2}) {
+		t.Errorf("first page = %v, want [1 2]", got)
+	}
+	if !pagination.HasMore || pagination.NextCursor == "" || pagination.Limit != 2 || pagination.Sort != request.SortRelevance {
+		t.Fatalf("unexpected first pagination %+v", pagination)
+	}
+
+	req.Cursor = pagination.NextCursor
+	second, pagination, err := index.Search(req, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hitKeys(second); !equalKeys(got, []uint{10}) {
+		t.Errorf("second page = %v, want [10]", got)
+	}
+	if pagination.HasMore || pagination.NextCursor != "" {
+		t.Errorf("unexpected second pagination %+v", pagination)
+	}
+
+	// A cursor only works with the sort it was made for
+	req.Sort = request.SortNewest
+	if _, _, err := index.Search(req, []uint{1}); err == nil || err.Error() != helper.InvalidCursor {
+		t.Errorf("err = %v, want %q", err, helper.InvalidCursor)
+	}
+
+	req.Sort = request.SortTop
+	req.Cursor = ""
+	if _, _, err := index.Search(req, []uint{1}); err == nil || err.Error() != helper.SortNotSupported {
+		t.Errorf("err = %v, want %q", err, helper.SortNotSupported)
+	}
+}
+
+func TestMemorySearchIndexWrites(t *testing.T) {
+	index := newTestSearchIndex(t)
+
+	// Renaming a thread renames it on its replies too
+	err := index.Index(&SearchDocument{ForumID: 1, ForumName: "Go", ThreadID: 1, ThreadTitle: "Type parameters", Text: "A look at type parameters", CreatedAt: searchTestTime})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits, _, err := index.Search(&request.ReqSearch{Query: "shorter"}, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].ThreadTitle != "Type parameters" {
+		t.Errorf("reply hits = %+v, want the new thread title", hits)
+	}
+
+	// Removing a thread removes its replies
+	if err := index.RemoveThread(1); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, _, err = index.Search(&request.ReqSearch{Query: "generics"}, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitKeys(hits); !equalKeys(got, []uint{2}) {
+		t.Errorf("hits after removing thread 1 = %v, want [2]", got)
+	}
+
+	// Removing a forum removes everything posted in it
+	if err := index.RemoveForum(2); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, _, err = index.Search(&request.ReqSearch{Query: "generics"}, []uint{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitKeys(hits); !equalKeys(got, []uint{2}) {
+		t.Errorf("hits after removing forum 2 = %v, want [2]", got)
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/database"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository interface {
+	WithTx(tx *gorm.DB) SessionRepository
+	CreateSession(userID uint) (*models.Session, error)
+	GetSessionByID(id uint) (*models.Session, error)
+	RevokeSession(session *models.Session) error
+	RevokeUserSessions(userID uint) error
+	CreateRefreshToken(session *models.Session, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error)
+	GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error)
+	RevokeRefreshToken(refreshToken *models.RefreshToken) error
+}
+
+type sessionRepository struct {
+	db *database.Database
+}
+
+func NewSessionRepository(db *database.Database) SessionRepository {
+	return &sessionRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *sessionRepository) WithTx(tx *gorm.DB) SessionRepository {
+	return &sessionRepository{txDatabase(tx)}
+}
+
+func (r *sessionRepository) CreateSession(userID uint) (*models.Session, error) {
+	session := &models.Session{
+		UserID: userID,
+	}
+
+	err := r.db.DB.Create(&session).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) GetSessionByID(id uint) (*models.Session, error) {
+	var session models.Session
+	err := r.db.DB.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *sessionRepository) RevokeSession(session *models.Session) error {
+	now := time.Now()
+
+	err := r.db.DB.Model(&session).Where("revoked_at IS NULL").Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	// revoke every refresh token that still belongs to the session
+	return r.db.DB.
+		Model(&models.RefreshToken{}).
+		Where("session_id = ?", session.ID).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+}
+
+func (r *sessionRepository) RevokeUserSessions(userID uint) error {
+	now := time.Now()
+
+	err := r.db.DB.
+		Model(&models.RefreshToken{}).
+		Where("session_id IN (SELECT id FROM sessions WHERE user_id = ?)", userID).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	return r.db.DB.
+		Model(&models.Session{}).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+}
+
+func (r *sessionRepository) CreateRefreshToken(session *models.Session, tokenHash string, expiresAt time.Time) (*models.RefreshToken, error) {
+	refreshToken := &models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+
+	err := r.db.DB.Create(&refreshToken).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return refreshToken, nil
+}
+
+func (r *sessionRepository) GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	var refreshToken models.RefreshToken
+	err := r.db.DB.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&refreshToken).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *sessionRepository) RevokeRefreshToken(refreshToken *models.RefreshToken) error {
+	return r.db.DB.Model(&refreshToken).Update("revoked_at", time.Now()).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/drdofx/talk-parmad/internal/api/database"
@@ -8,22 +9,36 @@ import (
 	"github.com/drdofx/talk-parmad/internal/api/models"
 	"github.com/drdofx/talk-parmad/internal/api/request"
 	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ThreadRepository interface {
+	WithTx(tx *gorm.DB) ThreadRepository
 	GetThreadByID(id uint) (*models.Thread, error)
 	CreateThread(req *request.ReqSaveThread, forumID uint, userID uint) (*models.Thread, error)
-	CreateOrUpdateThreadVote(thread *models.Thread, req *request.ReqVoteThread, userID uint) (*models.ThreadVote, error)
+	// CreateOrUpdateThreadVote also reports whether the vote changed
+	CreateOrUpdateThreadVote(thread *models.Thread, req *request.ReqVoteThread, userID uint) (*models.ThreadVote, bool, error)
+	DeleteThreadVote(thread *models.Thread, userID uint) error
 	UpdateThread(thread *models.Thread, req *request.ReqEditThread) (*models.Thread, error)
-	DetailThread(threadID uint) (*response.ResDetailThread, error)
-	ListUserThread(user *lib.UserData) ([]*response.ResListThread, error)
-	ListUserReply(user *lib.UserData) ([]*response.ResListThreadReply, error)
+	DetailThread(threadID uint, userID uint, depth int, pagination *request.ReqPagination) (*response.ResDetailThread, *response.ResPagination, error)
+	ListReplyChildren(replyID uint, userID uint, depth int, pagination *request.ReqPagination) ([]response.ResReplyField, *response.ResPagination, error)
+	ListUserThread(user *lib.UserData, pagination *request.ReqPagination) ([]*response.ResListThread, *response.ResPagination, error)
+	ListUserReply(user *lib.UserData, pagination *request.ReqPagination) ([]*response.ResListThreadReply, *response.ResPagination, error)
+	CountUserThreads(userID uint, viewerID uint) (int64, error)
+	CountUserReplies(userID uint, viewerID uint) (int64, error)
 	GetReplyByID(id uint) (*models.Reply, error)
-	CreateReply(req *request.ReqSaveReply, threadID uint, userID uint) (*models.Reply, error)
-	CreateOrUpdateReplyVote(reply *models.Reply, req *request.ReqVoteReply, userID uint) (*models.ReplyVote, error)
+	CreateReply(req *request.ReqSaveReply, threadID uint, parentReplyID *uint, userID uint) (*models.Reply, error)
+	// CreateOrUpdateReplyVote also reports whether the vote changed
+	CreateOrUpdateReplyVote(reply *models.Reply, req *request.ReqVoteReply, userID uint) (*models.ReplyVote, bool, error)
+	DeleteReplyVote(reply *models.Reply, userID uint) error
 	UpdateReply(reply *models.Reply, req *request.ReqEditReply) (*models.Reply, error)
 	DeleteThread(thread *models.Thread) error
-	DeleteReply(reply *models.Reply) error
+	GetDeletedThreadByID(id uint) (*models.Thread, error)
+	RestoreThread(thread *models.Thread) error
+	ListThreadReplies(threadID uint) ([]models.Reply, error)
+	DeleteReply(reply *models.Reply) ([]uint, error)
+	RecountVotes() error
 }
 
 type threadRepository struct {
@@ -34,6 +49,11 @@ func NewThreadRepository(db *database.Database) ThreadRepository {
 	return &threadRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *threadRepository) WithTx(tx *gorm.DB) ThreadRepository {
+	return &threadRepository{txDatabase(tx)}
+}
+
 func (r *threadRepository) CreateThread(req *request.ReqSaveThread, forumID uint, userID uint) (*models.Thread, error) {
 	thread := models.Thread{
 		ForumID:   forumID,
@@ -60,27 +80,104 @@ func (r *threadRepository) GetThreadByID(id uint) (*models.Thread, error) {
 	return &thread, nil
 }
 
-func (r *threadRepository) CreateOrUpdateThreadVote(thread *models.Thread, req *request.ReqVoteThread, userID uint) (*models.ThreadVote, error) {
+func (r *threadRepository) CreateOrUpdateThreadVote(thread *models.Thread, req *request.ReqVoteThread, userID uint) (*models.ThreadVote, bool, error) {
 	var threadVote models.ThreadVote
 
-	// first, try to update
-	update := r.db.DB.Model(&threadVote).Where("thread_id = ?", thread.ID).Where("user_id = ?", userID).Scan(&threadVote).Update("vote", req.Vote)
+	if err := r.lockVoteTarget(&models.Thread{}, thread.ID); err != nil {
+		return nil, false, err
+	}
 
-	if update.RowsAffected == 0 {
-		// if no rows affected, create
+	// first, look for an existing vote of the user, including a retracted one
+	err := r.db.DB.
+		Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("thread_id = ?", thread.ID).
+		Where("user_id = ?", userID).
+		First(&threadVote).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// if there is none, create it and count it
 		threadVote = models.ThreadVote{
 			ThreadID: thread.ID,
 			UserID:   userID,
 			Vote:     req.Vote,
 		}
 
-		err := r.db.DB.Create(&threadVote).Error
+		if err := r.db.DB.Create(&threadVote).Error; err != nil {
+			return nil, false, err
+		}
+
+		if err := r.adjustVoteCounter(&models.Thread{}, thread.ID, req.Vote, 1); err != nil {
+			return nil, false, err
+		}
+
+		return &threadVote, true, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// bring back a retracted vote and count it again
+	if threadVote.DeletedAt.Valid {
+		err := r.db.DB.
+			Unscoped().
+			Model(&threadVote).
+			Updates(map[string]interface{}{"vote": req.Vote, "deleted_at": nil}).Error
 		if err != nil {
-			return nil, err
+			return nil, false, err
+		}
+
+		if err := r.adjustVoteCounter(&models.Thread{}, thread.ID, req.Vote, 1); err != nil {
+			return nil, false, err
 		}
+
+		return &threadVote, true, nil
+	}
+
+	// nothing to do when the vote did not change
+	if threadVote.Vote == req.Vote {
+		return &threadVote, false, nil
+	}
+
+	// flip the vote, moving it from one counter to the other
+	if err := r.db.DB.Model(&threadVote).Update("vote", req.Vote).Error; err != nil {
+		return nil, false, err
+	}
+
+	if err := r.adjustVoteCounter(&models.Thread{}, thread.ID, !req.Vote, -1); err != nil {
+		return nil, false, err
 	}
 
-	return &threadVote, nil
+	if err := r.adjustVoteCounter(&models.Thread{}, thread.ID, req.Vote, 1); err != nil {
+		return nil, false, err
+	}
+
+	return &threadVote, true, nil
+}
+
+func (r *threadRepository) DeleteThreadVote(thread *models.Thread, userID uint) error {
+	var threadVote models.ThreadVote
+
+	if err := r.lockVoteTarget(&models.Thread{}, thread.ID); err != nil {
+		return err
+	}
+
+	err := r.db.DB.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("thread_id = ?", thread.ID).
+		Where("user_id = ?", userID).
+		First(&threadVote).Error
+	if err != nil {
+		return err
+	}
+
+	// soft-delete the vote and take it out of the counter
+	if err := r.db.DB.Delete(&threadVote).Error; err != nil {
+		return err
+	}
+
+	return r.adjustVoteCounter(&models.Thread{}, thread.ID, threadVote.Vote, -1)
 }
 
 func (r *threadRepository) UpdateThread(thread *models.Thread, req *request.ReqEditThread) (*models.Thread, error) {
@@ -93,109 +190,269 @@ func (r *threadRepository) UpdateThread(thread *models.Thread, req *request.ReqE
 	return thread, nil
 }
 
-func (r *threadRepository) DetailThread(threadID uint) (*response.ResDetailThread, error) {
+// DetailThread returns the thread with a page of its top level replies.
+// Every reply carries its children down to depth levels of replies.
+func (r *threadRepository) DetailThread(threadID uint, userID uint, depth int, pagination *request.ReqPagination) (*response.ResDetailThread, *response.ResPagination, error) {
 	var res response.ResDetailThread
 
+	page, err := newPage(threadReplyPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	threadQuery := `
-		SELECT t.id as id, t.title, t.text, t.created_at, u.name as created_by, SUM(CASE WHEN tv.vote = true THEN 1 ELSE 0 END) as total_upvotes, SUM(CASE WHEN tv.vote = false THEN 1 ELSE 0 END) as total_downvotes
+		SELECT t.id as id, t.title, t.text, t.created_at, u.name as created_by, t.number_of_upvotes as total_upvotes, t.number_of_downvotes as total_downvotes,
+			(SELECT tv.vote FROM thread_votes tv WHERE tv.thread_id = t.id AND tv.user_id = ? AND tv.deleted_at IS NULL) as user_vote
 		FROM threads t
 		LEFT JOIN users u ON u.id = t.created_by
-		LEFT JOIN thread_votes tv ON tv.thread_id = t.id
-		WHERE t.id = ? 
+		WHERE t.id = ?
 		AND t.deleted_at IS NULL
-		GROUP BY t.id, u.name
 	`
 
 	// Execute the thread query
-	threadRows, err := r.db.DB.Raw(threadQuery, threadID).Rows()
+	threadRows, err := r.db.DB.Raw(threadQuery, userID, threadID).Rows()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer threadRows.Close()
 
 	// Scan thread data into threadField variable
 	if !threadRows.Next() {
-		return nil, fmt.Errorf("thread not found")
+		return nil, nil, fmt.Errorf("thread not found")
 	}
 
 	var threadField response.ResThreadField
-	err = threadRows.Scan(&threadField.ID, &threadField.Title, &threadField.Text, &threadField.CreatedAt, &res.CreatedBy, &res.TotalUpvotes, &res.TotalDownvotes)
+	err = threadRows.Scan(&threadField.ID, &threadField.Title, &threadField.Text, &threadField.CreatedAt, &res.CreatedBy, &res.TotalUpvotes, &res.TotalDownvotes, &res.UserVote)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Assign threadField to res.ThreadData
 	res.ThreadData = threadField
 
-	// Retrieve replies for the thread
-	repliesQuery := `
-		SELECT r.id as id, r.text, r.created_at, u2.name as created_by, SUM(CASE WHEN rv.vote = true THEN 1 ELSE 0 END) as total_upvotes, SUM(CASE WHEN rv.vote = false THEN 1 ELSE 0 END) as total_downvotes
-		FROM replies r
-		LEFT JOIN users u2 ON u2.id = r.created_by
-		LEFT JOIN reply_votes rv ON rv.reply_id = r.id
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("r.id ASC")
+
+	// Retrieve the top level replies for the thread
+	repliesQuery := replyFieldsQuery + `
 		WHERE r.thread_id = ?
+		AND r.parent_reply_id IS NULL
 		AND r.deleted_at IS NULL
-		GROUP BY r.id, u2.name
+	` + cursorWhere + orderBy
+
+	repliesArgs := append(append([]interface{}{userID, threadID}, cursorArgs...), orderArgs...)
+
+	res.ReplyData, err = r.queryReplyFields(repliesQuery, repliesArgs...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pageRes *response.ResPagination
+	res.ReplyData, pageRes, err = paginate(r.db.DB, page, res.ReplyData, func(reply response.ResReplyField) uint { return reply.ID })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.loadReplyChildren(res.ReplyData, userID, depth); err != nil {
+		return nil, nil, err
+	}
+
+	if err := loadAttachments(r.db.DB, &res.ThreadData, res.ReplyData); err != nil {
+		return nil, nil, err
+	}
+
+	// Count every reply of the thread, not only the current page. Replies
+	// under a deleted reply aren't shown, so they aren't counted either.
+	totalQuery := `
+		WITH RECURSIVE shown AS (
+			SELECT r.id
+			FROM replies r
+			WHERE r.thread_id = ?
+			AND r.parent_reply_id IS NULL
+			AND r.deleted_at IS NULL
+			UNION ALL
+			SELECT r.id
+			FROM replies r
+			INNER JOIN shown s ON r.parent_reply_id = s.id
+			WHERE r.deleted_at IS NULL
+		)
+		SELECT COUNT(*) FROM shown
 	`
 
-	// Execute the replies query
-	repliesRows, err := r.db.DB.Raw(repliesQuery, threadID).Rows()
+	err = r.db.DB.Raw(totalQuery, threadID).Scan(&res.TotalReplies).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer repliesRows.Close()
 
-	// Iterate over the replies and append them to the ResDetailThread struct
-	for repliesRows.Next() {
-		var reply response.ResReplyField
-		err := repliesRows.Scan(&reply.ID, &reply.Text, &reply.CreatedAt, &reply.CreatedBy, &reply.TotalUpvotes, &reply.TotalDownvotes)
-		if err != nil {
-			return nil, err
-		}
-		res.ReplyData = append(res.ReplyData, reply)
+	return &res, pageRes, nil
+}
+
+// ListReplyChildren returns a page of the direct replies to a reply, each one
+// with its own children down to depth levels. It backs the "load more"
+// links of branches cut off by DetailThread.
+func (r *threadRepository) ListReplyChildren(replyID uint, userID uint, depth int, pagination *request.ReqPagination) ([]response.ResReplyField, *response.ResPagination, error) {
+	page, err := newPage(threadReplyPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorWhere, cursorArgs := page.where()
+	orderBy, orderArgs := page.orderLimit("r.id ASC")
+
+	query := replyFieldsQuery + `
+		WHERE r.parent_reply_id = ?
+		AND r.deleted_at IS NULL
+	` + cursorWhere + orderBy
+
+	args := append(append([]interface{}{userID, replyID}, cursorArgs...), orderArgs...)
+
+	replies, err := r.queryReplyFields(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replies, pageRes, err := paginate(r.db.DB, page, replies, func(reply response.ResReplyField) uint { return reply.ID })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.loadReplyChildren(replies, userID, depth); err != nil {
+		return nil, nil, err
 	}
 
-	res.TotalReplies = len(res.ReplyData)
-	return &res, nil
+	if err := loadAttachments(r.db.DB, nil, replies); err != nil {
+		return nil, nil, err
+	}
+
+	return replies, pageRes, nil
 }
 
-func (r *threadRepository) ListUserThread(user *lib.UserData) ([]*response.ResListThread, error) {
+// ListUserThread lists the threads created by the user. A nil pagination
+// returns every thread.
+func (r *threadRepository) ListUserThread(user *lib.UserData, pagination *request.ReqPagination) ([]*response.ResListThread, *response.ResPagination, error) {
+	page, err := newPage(threadPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var res []*response.ResListThread
 
-	err := r.db.DB.
+	query := r.db.DB.
 		Table("threads t").
 		Select("t.*, f.forum_name, f.forum_image").
 		Joins("LEFT JOIN forums f ON f.id = t.forum_id").
 		Where("t.created_by = ?", user.UserID).
 		Where("t.deleted_at IS NULL").
-		Order("t.created_at DESC").
-		Scan(&res).Error
+		Where(readableForumSQL("f"), user.UserID)
 
+	err = page.apply(query, "t.created_at DESC").Scan(&res).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return res, nil
+	return paginate(r.db.DB, page, res, func(t *response.ResListThread) uint { return t.ID })
 }
 
-func (r *threadRepository) ListUserReply(user *lib.UserData) ([]*response.ResListThreadReply, error) {
-	var res []*response.ResListThreadReply
+// ListUserReply lists the replies created by the user together with the
+// thread they belong to. A nil pagination returns every reply.
+func (r *threadRepository) ListUserReply(user *lib.UserData, pagination *request.ReqPagination) ([]*response.ResListThreadReply, *response.ResPagination, error) {
+	page, err := newPage(replyPageSource, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	err := r.db.DB.
+	// threads and replies share column names, so they are loaded separately
+	// instead of being scanned from a single joined row
+	type userReplyRow struct {
+		models.Reply
+		ForumName  string
+		ForumImage string
+	}
+
+	var rows []userReplyRow
+
+	query := r.db.DB.
 		Table("replies r").
-		Select("t.*, r.*, f.forum_name, f.forum_image").
-		Joins("LEFT JOIN threads t ON t.id = r.thread_id").
-		Joins("LEFT JOIN forums f ON f.id = t.forum_id").
+		Select("r.*, f.forum_name, f.forum_image").
+		Joins("INNER JOIN threads t ON t.id = r.thread_id").
+		Joins("INNER JOIN forums f ON f.id = t.forum_id").
 		Where("r.created_by = ?", user.UserID).
 		Where("r.deleted_at IS NULL").
-		Order("r.created_at DESC").
-		Scan(&res).Error
+		Where("t.deleted_at IS NULL").
+		Where(readableForumSQL("f"), user.UserID)
 
+	err = page.apply(query, "r.created_at DESC").Scan(&rows).Error
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	rows, pageRes, err := paginate(r.db.DB, page, rows, func(row userReplyRow) uint { return row.ID })
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return res, nil
+	res := make([]*response.ResListThreadReply, 0, len(rows))
+	if len(rows) == 0 {
+		return res, pageRes, nil
+	}
+
+	threadIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		threadIDs = append(threadIDs, row.ThreadID)
+	}
+
+	var threads []models.Thread
+	if err := r.db.DB.Where("id IN ?", threadIDs).Find(&threads).Error; err != nil {
+		return nil, nil, err
+	}
+
+	threadByID := make(map[uint]models.Thread, len(threads))
+	for _, thread := range threads {
+		threadByID[thread.ID] = thread
+	}
+
+	for _, row := range rows {
+		res = append(res, &response.ResListThreadReply{
+			Thread:     threadByID[row.ThreadID],
+			Reply:      row.Reply,
+			ForumName:  row.ForumName,
+			ForumImage: row.ForumImage,
+		})
+	}
+
+	return res, pageRes, nil
+}
+
+// CountUserThreads counts the threads created by the user in the forums
+// viewer may read
+func (r *threadRepository) CountUserThreads(userID uint, viewerID uint) (int64, error) {
+	var count int64
+	err := r.db.DB.
+		Table("threads t").
+		Joins("INNER JOIN forums f ON f.id = t.forum_id").
+		Where("t.created_by = ?", userID).
+		Where("t.deleted_at IS NULL").
+		Where(readableForumSQL("f"), viewerID).
+		Count(&count).Error
+
+	return count, err
+}
+
+// CountUserReplies counts the replies created by the user in the forums
+// viewer may read
+func (r *threadRepository) CountUserReplies(userID uint, viewerID uint) (int64, error) {
+	var count int64
+	err := r.db.DB.
+		Table("replies r").
+		Joins("INNER JOIN threads t ON t.id = r.thread_id").
+		Joins("INNER JOIN forums f ON f.id = t.forum_id").
+		Where("r.created_by = ?", userID).
+		Where("r.deleted_at IS NULL").
+		Where("t.deleted_at IS NULL").
+		Where(readableForumSQL("f"), viewerID).
+		Count(&count).Error
+
+	return count, err
 }
 
 func (r *threadRepository) GetReplyByID(id uint) (*models.Reply, error) {
@@ -209,11 +466,12 @@ func (r *threadRepository) GetReplyByID(id uint) (*models.Reply, error) {
 	return &reply, nil
 }
 
-func (r *threadRepository) CreateReply(req *request.ReqSaveReply, threadID uint, userID uint) (*models.Reply, error) {
+func (r *threadRepository) CreateReply(req *request.ReqSaveReply, threadID uint, parentReplyID *uint, userID uint) (*models.Reply, error) {
 	reply := models.Reply{
-		ThreadID:  threadID,
-		CreatedBy: userID,
-		Text:      req.Text,
+		ThreadID:      threadID,
+		ParentReplyID: parentReplyID,
+		CreatedBy:     userID,
+		Text:          req.Text,
 	}
 
 	err := r.db.DB.Create(&reply).Error
@@ -224,27 +482,104 @@ func (r *threadRepository) CreateReply(req *request.ReqSaveReply, threadID uint,
 	return &reply, nil
 }
 
-func (r *threadRepository) CreateOrUpdateReplyVote(reply *models.Reply, req *request.ReqVoteReply, userID uint) (*models.ReplyVote, error) {
+func (r *threadRepository) CreateOrUpdateReplyVote(reply *models.Reply, req *request.ReqVoteReply, userID uint) (*models.ReplyVote, bool, error) {
 	var replyVote models.ReplyVote
 
-	// first, try to update
-	update := r.db.DB.Model(&replyVote).Where("reply_id = ?", reply.ID).Where("user_id = ?", userID).Scan(&replyVote).Update("vote", req.Vote)
+	if err := r.lockVoteTarget(&models.Reply{}, reply.ID); err != nil {
+		return nil, false, err
+	}
 
-	if update.RowsAffected == 0 {
-		// if no rows affected, create
+	// first, look for an existing vote of the user, including a retracted one
+	err := r.db.DB.
+		Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reply_id = ?", reply.ID).
+		Where("user_id = ?", userID).
+		First(&replyVote).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// if there is none, create it and count it
 		replyVote = models.ReplyVote{
 			ReplyID: reply.ID,
 			UserID:  userID,
 			Vote:    req.Vote,
 		}
 
-		err := r.db.DB.Create(&replyVote).Error
+		if err := r.db.DB.Create(&replyVote).Error; err != nil {
+			return nil, false, err
+		}
+
+		if err := r.adjustVoteCounter(&models.Reply{}, reply.ID, req.Vote, 1); err != nil {
+			return nil, false, err
+		}
+
+		return &replyVote, true, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// bring back a retracted vote and count it again
+	if replyVote.DeletedAt.Valid {
+		err := r.db.DB.
+			Unscoped().
+			Model(&replyVote).
+			Updates(map[string]interface{}{"vote": req.Vote, "deleted_at": nil}).Error
 		if err != nil {
-			return nil, err
+			return nil, false, err
+		}
+
+		if err := r.adjustVoteCounter(&models.Reply{}, reply.ID, req.Vote, 1); err != nil {
+			return nil, false, err
 		}
+
+		return &replyVote, true, nil
+	}
+
+	// nothing to do when the vote did not change
+	if replyVote.Vote == req.Vote {
+		return &replyVote, false, nil
+	}
+
+	// flip the vote, moving it from one counter to the other
+	if err := r.db.DB.Model(&replyVote).Update("vote", req.Vote).Error; err != nil {
+		return nil, false, err
 	}
 
-	return &replyVote, nil
+	if err := r.adjustVoteCounter(&models.Reply{}, reply.ID, !req.Vote, -1); err != nil {
+		return nil, false, err
+	}
+
+	if err := r.adjustVoteCounter(&models.Reply{}, reply.ID, req.Vote, 1); err != nil {
+		return nil, false, err
+	}
+
+	return &replyVote, true, nil
+}
+
+func (r *threadRepository) DeleteReplyVote(reply *models.Reply, userID uint) error {
+	var replyVote models.ReplyVote
+
+	if err := r.lockVoteTarget(&models.Reply{}, reply.ID); err != nil {
+		return err
+	}
+
+	err := r.db.DB.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reply_id = ?", reply.ID).
+		Where("user_id = ?", userID).
+		First(&replyVote).Error
+	if err != nil {
+		return err
+	}
+
+	// soft-delete the vote and take it out of the counter
+	if err := r.db.DB.Delete(&replyVote).Error; err != nil {
+		return err
+	}
+
+	return r.adjustVoteCounter(&models.Reply{}, reply.ID, replyVote.Vote, -1)
 }
 
 func (r *threadRepository) UpdateReply(reply *models.Reply, req *request.ReqEditReply) (*models.Reply, error) {
@@ -257,22 +592,256 @@ func (r *threadRepository) UpdateReply(reply *models.Reply, req *request.ReqEdit
 	return reply, nil
 }
 
+// DeleteThread soft-deletes the thread together with its replies and votes,
+// all in one deletion batch
 func (r *threadRepository) DeleteThread(thread *models.Thread) error {
-	err := r.db.DB.Delete(&thread).Error
+	return softDeleteBatch(r.db.DB, thread.ID, []batchDelete{
+		{"reply_votes", "reply_id IN (SELECT id FROM replies WHERE thread_id = ?)"},
+		{"replies", "thread_id = ?"},
+		{"thread_votes", "thread_id = ?"},
+		{"threads", "id = ?"},
+	})
+}
 
+func (r *threadRepository) GetDeletedThreadByID(id uint) (*models.Thread, error) {
+	var thread models.Thread
+	err := r.db.DB.Unscoped().Where("id = ?", id).Where("deleted_at IS NOT NULL").First(&thread).Error
 	if err != nil {
+		return nil, err
+	}
+
+	return &thread, nil
+}
+
+// RestoreThread brings back the thread and everything its deletion removed
+func (r *threadRepository) RestoreThread(thread *models.Thread) error {
+	return restoreDeleted(r.db.DB, "threads", thread.ID, thread.DeletionBatch)
+}
+
+func (r *threadRepository) ListThreadReplies(threadID uint) ([]models.Reply, error) {
+	var replies []models.Reply
+	err := r.db.DB.Where("thread_id = ?", threadID).Find(&replies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return replies, nil
+}
+
+// DeleteReply soft-deletes the reply together with the replies under it and
+// their votes, all in one deletion batch. It returns the ids of the deleted
+// replies.
+func (r *threadRepository) DeleteReply(reply *models.Reply) ([]uint, error) {
+	subtreeQuery := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM replies WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT r.id
+			FROM replies r
+			INNER JOIN subtree s ON r.parent_reply_id = s.id
+			WHERE r.deleted_at IS NULL
+		)
+		SELECT id FROM subtree
+	`
+
+	var ids []uint
+	if err := r.db.DB.Raw(subtreeQuery, reply.ID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	err := softDeleteBatch(r.db.DB, ids, []batchDelete{
+		{"reply_votes", "reply_id IN ?"},
+		{"replies", "id IN ?"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// RecountVotes recomputes the denormalized vote counters of every thread and
+// reply from the vote tables
+func (r *threadRepository) RecountVotes() error {
+	threadQuery := `
+		UPDATE threads t
+		SET t.number_of_upvotes = (
+			SELECT COUNT(*) FROM thread_votes tv
+			WHERE tv.thread_id = t.id AND tv.vote = true AND tv.deleted_at IS NULL
+		),
+		t.number_of_downvotes = (
+			SELECT COUNT(*) FROM thread_votes tv
+			WHERE tv.thread_id = t.id AND tv.vote = false AND tv.deleted_at IS NULL
+		)
+	`
+
+	if err := r.db.DB.Exec(threadQuery).Error; err != nil {
 		return err
 	}
 
-	return nil
+	replyQuery := `
+		UPDATE replies r
+		SET r.number_of_upvotes = (
+			SELECT COUNT(*) FROM reply_votes rv
+			WHERE rv.reply_id = r.id AND rv.vote = true AND rv.deleted_at IS NULL
+		),
+		r.number_of_downvotes = (
+			SELECT COUNT(*) FROM reply_votes rv
+			WHERE rv.reply_id = r.id AND rv.vote = false AND rv.deleted_at IS NULL
+		)
+	`
+
+	return r.db.DB.Exec(replyQuery).Error
+}
+
+// lockVoteTarget locks the thread or reply identified by model and id, so
+// that the votes on it are changed one at a time. It is taken before the
+// vote row, which keeps concurrent first votes from deadlocking on the gap
+// locks of the vote lookup.
+func (r *threadRepository) lockVoteTarget(model interface{}, id uint) error {
+	return r.db.DB.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", id).
+		Take(model).Error
+}
+
+// adjustVoteCounter adds delta to the upvote or downvote counter of the
+// thread or reply identified by model and id
+func (r *threadRepository) adjustVoteCounter(model interface{}, id uint, vote bool, delta int) error {
+	column := "number_of_downvotes"
+	if vote {
+		column = "number_of_upvotes"
+	}
+
+	return r.db.DB.
+		Model(model).
+		Where("id = ?", id).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+}
+
+// replyFieldsQuery selects the fields of response.ResReplyField, in the
+// order queryReplyFields scans them. The first arg is the user whose vote is
+// returned.
+const replyFieldsQuery = `
+	SELECT r.id as id, r.parent_reply_id, r.text, r.created_at, u2.name as created_by, r.number_of_upvotes as total_upvotes, r.number_of_downvotes as total_downvotes,
+		(SELECT rv.vote FROM reply_votes rv WHERE rv.reply_id = r.id AND rv.user_id = ? AND rv.deleted_at IS NULL) as user_vote
+	FROM replies r
+	LEFT JOIN users u2 ON u2.id = r.created_by
+`
+
+func (r *threadRepository) queryReplyFields(query string, args ...interface{}) ([]response.ResReplyField, error) {
+	rows, err := r.db.DB.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []response.ResReplyField
+	for rows.Next() {
+		var reply response.ResReplyField
+		err := rows.Scan(&reply.ID, &reply.ParentReplyID, &reply.Text, &reply.CreatedAt, &reply.CreatedBy, &reply.TotalUpvotes, &reply.TotalDownvotes, &reply.UserVote)
+		if err != nil {
+			return nil, err
+		}
+
+		replies = append(replies, reply)
+	}
+
+	return replies, rows.Err()
 }
 
-func (r *threadRepository) DeleteReply(reply *models.Reply) error {
-	err := r.db.DB.Delete(&reply).Error
+// loadReplyChildren fills in the children of the replies level by level,
+// down to depth levels counting the replies themselves. Each branch shows at
+// most repliesPerBranch children; a branch cut off by that limit gets a
+// cursor for ListReplyChildren, and a reply at the last level only tells
+// whether it has children at all.
+func (r *threadRepository) loadReplyChildren(replies []response.ResReplyField, userID uint, depth int) error {
+	parents := make([]*response.ResReplyField, 0, len(replies))
+	for i := range replies {
+		parents = append(parents, &replies[i])
+	}
+
+	for level := 1; level < depth && len(parents) > 0; level++ {
+		query := `
+			SELECT id, parent_reply_id, text, created_at, created_by, total_upvotes, total_downvotes, user_vote
+			FROM (
+				SELECT branch.*, ROW_NUMBER() OVER (PARTITION BY branch.parent_reply_id ORDER BY branch.id) AS branch_row
+				FROM (` + replyFieldsQuery + `
+					WHERE r.parent_reply_id IN ?
+					AND r.deleted_at IS NULL
+				) AS branch
+			) AS children
+			WHERE branch_row <= ?
+			ORDER BY parent_reply_id, id
+		`
+
+		children, err := r.queryReplyFields(query, userID, replyIDs(parents), repliesPerBranch+1)
+		if err != nil {
+			return err
+		}
 
+		byParent := make(map[uint][]response.ResReplyField, len(parents))
+		for _, child := range children {
+			byParent[*child.ParentReplyID] = append(byParent[*child.ParentReplyID], child)
+		}
+
+		var next []*response.ResReplyField
+		for _, parent := range parents {
+			parent.Children = byParent[parent.ID]
+
+			if len(parent.Children) > repliesPerBranch {
+				parent.Children = parent.Children[:repliesPerBranch]
+				parent.HasMoreChildren = true
+				parent.ChildrenCursor = encodeCursor(pageCursor{
+					Sort: request.SortOldest,
+					ID:   parent.Children[len(parent.Children)-1].ID,
+				})
+			}
+
+			for i := range parent.Children {
+				next = append(next, &parent.Children[i])
+			}
+		}
+
+		parents = next
+	}
+
+	if len(parents) == 0 {
+		return nil
+	}
+
+	var withChildren []uint
+	err := r.db.DB.
+		Model(&models.Reply{}).
+		Distinct("parent_reply_id").
+		Where("parent_reply_id IN ?", replyIDs(parents)).
+		Pluck("parent_reply_id", &withChildren).Error
 	if err != nil {
 		return err
 	}
 
+	hasChildren := make(map[uint]bool, len(withChildren))
+	for _, id := range withChildren {
+		hasChildren[id] = true
+	}
+
+	for _, parent := range parents {
+		parent.HasMoreChildren = hasChildren[parent.ID]
+	}
+
 	return nil
 }
+
+func replyIDs(replies []*response.ResReplyField) []uint {
+	ids := make([]uint, 0, len(replies))
+	for _, reply := range replies {
+		ids = append(ids, reply.ID)
+	}
+
+	return ids
+}
@@ -1,19 +1,31 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/drdofx/talk-parmad/internal/api/database"
 	"github.com/drdofx/talk-parmad/internal/api/models"
 	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
+	WithTx(tx *gorm.DB) UserRepository
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByNIM(nim *string) (*models.User, error)
+	GetUserByID(id uint) (*models.User, error)
 	Create(req *request.ReqSaveUser) (*models.User, error)
-	// ReadById(id uint) (*models.User, error)
-	// ReadByUsername(username string) (*models.User, error)
-	// Update(user *models.User) (*models.User, error)
-	// Delete(user *models.User) error
+	Update(user *models.User, req *request.ReqUpdateProfile) (*models.User, error)
+	UpdateProfileImage(user *models.User, image string, thumbnail string) error
+	UpdatePassword(user *models.User, hashedPassword string) error
+	UpdateStatus(user *models.User, status string) error
+	UpdateRole(user *models.User, role string) error
+	SearchUsers(req *request.ReqAdminListUser) ([]models.User, *response.ResPagination, error)
+	CreatePasswordReset(user *models.User, tokenHash string, expiresAt time.Time) (*models.PasswordReset, error)
+	GetPasswordResetByHash(tokenHash string) (*models.PasswordReset, error)
+	UsePasswordReset(passwordReset *models.PasswordReset) error
 }
 
 type userRepository struct {
@@ -24,6 +36,11 @@ func NewUserRepository(db *database.Database) UserRepository {
 	return &userRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{txDatabase(tx)}
+}
+
 func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 	user := &models.User{}
 
@@ -44,9 +61,19 @@ func (r *userRepository) GetUserByNIM(nim *string) (*models.User, error) {
 	return user, nil
 }
 
+func (r *userRepository) GetUserByID(id uint) (*models.User, error) {
+	user := &models.User{}
+
+	if err := r.db.DB.Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (r *userRepository) Create(req *request.ReqSaveUser) (*models.User, error) {
 	user := &models.User{
-		Name:     "Test",
+		Name:     req.Name,
 		Email:    req.Email,
 		NIM:      &req.NIM,
 		Password: req.Password,
@@ -61,3 +88,108 @@ func (r *userRepository) Create(req *request.ReqSaveUser) (*models.User, error)
 	return user, nil
 
 }
+
+func (r *userRepository) Update(user *models.User, req *request.ReqUpdateProfile) (*models.User, error) {
+	err := r.db.DB.Model(&user).Updates(&req).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) UpdateProfileImage(user *models.User, image string, thumbnail string) error {
+	return r.db.DB.Model(&user).Updates(map[string]interface{}{
+		"profile_image":     image,
+		"profile_thumbnail": thumbnail,
+	}).Error
+}
+
+func (r *userRepository) UpdatePassword(user *models.User, hashedPassword string) error {
+	return r.db.DB.Model(&user).Update("password", hashedPassword).Error
+}
+
+func (r *userRepository) UpdateStatus(user *models.User, status string) error {
+	return r.db.DB.Model(&user).Update("status", status).Error
+}
+
+func (r *userRepository) UpdateRole(user *models.User, role string) error {
+	return r.db.DB.Model(&user).Update("role", role).Error
+}
+
+var userPageSource = pageSource{
+	table: "users u",
+	id:    "u.id",
+	sorts: map[string][]sortKey{
+		request.SortNewest: nil,
+		request.SortOldest: nil,
+	},
+}
+
+func (r *userRepository) SearchUsers(req *request.ReqAdminListUser) ([]models.User, *response.ResPagination, error) {
+	page, err := newPage(userPageSource, &req.ReqPagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var users []models.User
+
+	query := r.db.DB.
+		Table("users u").
+		Select("u.*").
+		Where("u.deleted_at IS NULL")
+
+	if req.Query != "" {
+		like := likeContains(req.Query)
+		query = query.Where("u.name LIKE ? OR u.email LIKE ? OR u.nim LIKE ?", like, like, like)
+	}
+
+	if req.Role != "" {
+		query = query.Where("u.role = ?", req.Role)
+	}
+
+	if req.Status != "" {
+		query = query.Where("u.status = ?", req.Status)
+	}
+
+	err = page.apply(query, "u.created_at DESC").Scan(&users).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paginate(r.db.DB, page, users, func(u models.User) uint { return u.ID })
+}
+
+func (r *userRepository) CreatePasswordReset(user *models.User, tokenHash string, expiresAt time.Time) (*models.PasswordReset, error) {
+	passwordReset := &models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+
+	err := r.db.DB.Create(&passwordReset).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return passwordReset, nil
+}
+
+func (r *userRepository) GetPasswordResetByHash(tokenHash string) (*models.PasswordReset, error) {
+	var passwordReset models.PasswordReset
+	err := r.db.DB.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&passwordReset).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &passwordReset, nil
+}
+
+func (r *userRepository) UsePasswordReset(passwordReset *models.PasswordReset) error {
+	return r.db.DB.Model(&passwordReset).Update("used_at", time.Now()).Error
+}
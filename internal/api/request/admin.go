@@ -0,0 +1,17 @@
+package request
+
+type ReqAdminListUser struct {
+	Query  string `json:"q" form:"q"`
+	Role   string `json:"role" form:"role" validate:"omitempty,oneof=Admin User"`
+	Status string `json:"status" form:"status" validate:"omitempty,oneof=Active Inactive"`
+	ReqPagination
+}
+
+type ReqAdminSetRole struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required,oneof=Admin User"`
+}
+
+type ReqAdminForum struct {
+	ForumID uint `json:"forum_id" validate:"required"`
+}
@@ -1,41 +1,114 @@
 package request
 
+import "time"
+
 type ReqSaveForum struct {
 	ForumName        string `json:"forum_name" validate:"required"`
 	IntroductionText string `json:"introduction_text"`
 	Category         string `json:"category"`
+	Visibility       string `json:"visibility" validate:"omitempty,oneof=public request_to_join invite_only"`
 }
 
 type ReqJoinForum struct {
 	ForumID uint `json:"forum_id" validate:"required"`
 }
 
-type ReqCheckModeratorForum struct {
+type ReqRequestToJoin struct {
+	ForumID uint   `json:"forum_id" validate:"required"`
+	Message string `json:"message" validate:"max=1000"`
+}
+
+type ReqListJoinRequest struct {
+	ForumID uint `json:"forum_id" form:"id" validate:"required"`
+	ReqPagination
+}
+
+type ReqReviewJoinRequest struct {
+	RequestID uint   `json:"request_id" validate:"required"`
+	Status    string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+type ReqCreateInvite struct {
+	ForumID   uint       `json:"forum_id" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`                          // the invite never expires when empty
+	MaxUses   int        `json:"max_uses" validate:"min=0,max=10000"` // 0 means no limit
+}
+
+type ReqJoinByInvite struct {
+	Code string `json:"code" validate:"required,max=64"`
+}
+
+type ReqLeaveForum struct {
 	ForumID uint `json:"forum_id" validate:"required"`
-	UserID  uint `json:"-" validate:"required"`
 }
 
 type ReqEditForum struct {
-	ForumID          uint   `json:"forum_id" validate:"required"`
-	ForumName        string `json:"forum_name"`
-	IntroductionText string `json:"introduction_text"`
-	Category         string `json:"category"`
+	ForumID           uint   `json:"forum_id" validate:"required"`
+	ForumName         string `json:"forum_name"`
+	IntroductionText  string `json:"introduction_text"`
+	Category          string `json:"category"`
+	Visibility        string `json:"visibility" validate:"omitempty,oneof=public request_to_join invite_only"`
+	AttachmentMaxSize int64  `json:"attachment_max_size" validate:"omitempty,min=1,max=20971520"`
+	AttachmentTypes   string `json:"attachment_types" validate:"max=255"` // comma separated content types
 }
 
 type ReqDeleteForum struct {
 	ForumID uint `json:"forum_id" validate:"required"`
 }
 
+type ReqRestoreForum struct {
+	ForumID uint `json:"forum_id" validate:"required"`
+}
+
 type ReqDetailForum struct {
 	ForumID uint `json:"forum_id" form:"id" validate:"required"`
+	ReqPagination
 }
 
 type ReqRemoveFromForum struct {
-	ForumID uint `json:"forum_id" validate:"required"`
-	UserID  uint `json:"user_id" validate:"required"`
+	ForumID   uint       `json:"forum_id" validate:"required"`
+	UserID    uint       `json:"user_id" validate:"required"`
+	Reason    string     `json:"reason" validate:"required,max=1000"`
+	ExpiresAt *time.Time `json:"expires_at"` // the ban is permanent when empty
+}
+
+type ReqListBan struct {
+	ForumID uint `json:"forum_id" form:"id" validate:"required"`
+	ReqPagination
+}
+
+type ReqAppealBan struct {
+	ForumID uint   `json:"forum_id" validate:"required"`
+	Message string `json:"message" validate:"required,max=1000"`
+}
+
+type ReqReviewBanAppeal struct {
+	AppealID uint   `json:"appeal_id" validate:"required"`
+	Status   string `json:"status" validate:"required,oneof=accepted rejected"`
 }
 
 type ReqSearchForum struct {
 	ForumName string `json:"forum_name" form:"forum_name"`
 	Category  string `json:"category" form:"category"`
+	ReqPagination
+}
+
+type ReqListModerator struct {
+	ForumID uint `json:"forum_id" form:"id" validate:"required"`
+}
+
+type ReqPromoteModerator struct {
+	ForumID  uint   `json:"forum_id" validate:"required"`
+	UserID   uint   `json:"user_id" validate:"required"`
+	Nickname string `json:"nickname" validate:"max=255"`
+}
+
+type ReqDemoteModerator struct {
+	ForumID uint `json:"forum_id" validate:"required"`
+	UserID  uint `json:"user_id" validate:"required"`
+}
+
+type ReqTransferHeadModerator struct {
+	ForumID uint `json:"forum_id" validate:"required"`
+	UserID  uint `json:"user_id" validate:"required"`
 }
@@ -0,0 +1,10 @@
+package request
+
+type ReqListNotification struct {
+	Unread bool `json:"unread" form:"unread"`
+	ReqPagination
+}
+
+type ReqMarkNotificationRead struct {
+	NotificationIDs []uint `json:"notification_ids" validate:"required,min=1,max=100"`
+}
@@ -0,0 +1,17 @@
+package request
+
+const (
+	SortNewest      = "newest"
+	SortOldest      = "oldest"
+	SortTop         = "top"
+	SortMostReplied = "most_replied"
+	SortRelevance   = "relevance"
+)
+
+// ReqPagination holds the cursor pagination query params shared by every
+// list endpoint
+type ReqPagination struct {
+	Limit  int    `json:"limit" form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `json:"cursor" form:"cursor"`
+	Sort   string `json:"sort" form:"sort" validate:"omitempty,oneof=newest oldest top most_replied relevance"`
+}
@@ -0,0 +1,7 @@
+package request
+
+type ReqSearch struct {
+	Query   string `json:"q" form:"q" validate:"required,max=255"`
+	ForumID uint   `json:"forum_id" form:"forum_id"`
+	ReqPagination
+}
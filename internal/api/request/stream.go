@@ -0,0 +1,6 @@
+package request
+
+type ReqStream struct {
+	ForumIDs  []uint `json:"forum_ids" form:"forum_id" validate:"max=50"`
+	ThreadIDs []uint `json:"thread_ids" form:"thread_id" validate:"max=50"`
+}
@@ -1,9 +1,10 @@
 package request
 
 type ReqSaveThread struct {
-	ForumID string `json:"forum_id" validate:"req-numeric"`
-	Title   string `json:"title" validate:"required"`
-	Text    string `json:"text" validate:"required"`
+	ForumID       string `json:"forum_id" validate:"req-numeric"`
+	Title         string `json:"title" validate:"required"`
+	Text          string `json:"text" validate:"required,max=20000"`
+	AttachmentIDs []uint `json:"attachment_ids" validate:"max=10,unique"`
 }
 
 type ReqVoteThread struct {
@@ -11,15 +12,21 @@ type ReqVoteThread struct {
 	Vote     bool   `json:"vote"`
 }
 
+type ReqRetractVoteThread struct {
+	ThreadID string `json:"thread_id" validate:"req-numeric"`
+}
+
 type ReqEditThread struct {
 	ThreadID string `json:"thread_id" validate:"req-numeric"`
 	Title    string `json:"title"`
-	Text     string `json:"text"`
+	Text     string `json:"text" validate:"max=20000"`
 }
 
 type ReqSaveReply struct {
-	ThreadID string `json:"thread_id" validate:"req-numeric"`
-	Text     string `json:"text" validate:"required"`
+	ThreadID      string `json:"thread_id" validate:"req-numeric"`
+	ParentReplyID string `json:"parent_reply_id" validate:"omitempty,numeric"`
+	Text          string `json:"text" validate:"required,max=20000"`
+	AttachmentIDs []uint `json:"attachment_ids" validate:"max=10,unique"`
 }
 
 type ReqVoteReply struct {
@@ -27,19 +34,35 @@ type ReqVoteReply struct {
 	Vote    bool   `json:"vote"`
 }
 
+type ReqRetractVoteReply struct {
+	ReplyID string `json:"reply_id" validate:"req-numeric"`
+}
+
 type ReqEditReply struct {
 	ReplyID string `json:"reply_id" validate:"req-numeric"`
-	Text    string `json:"text"`
+	Text    string `json:"text" validate:"max=20000"`
 }
 
 type ReqDetailThread struct {
 	ThreadID string `json:"thread_id" form:"id" validate:"req-numeric"`
+	Depth    int    `json:"depth" form:"depth" validate:"omitempty,min=1,max=10"`
+	ReqPagination
+}
+
+type ReqListReplyChildren struct {
+	ReplyID string `json:"reply_id" form:"id" validate:"req-numeric"`
+	Depth   int    `json:"depth" form:"depth" validate:"omitempty,min=1,max=10"`
+	ReqPagination
 }
 
 type ReqDeleteThread struct {
 	ThreadID string `json:"thread_id" validate:"req-numeric"`
 }
 
+type ReqRestoreThread struct {
+	ThreadID string `json:"thread_id" validate:"req-numeric"`
+}
+
 type ReqDeleteReply struct {
 	ReplyID string `json:"reply_id" validate:"req-numeric"`
 }
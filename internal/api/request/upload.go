@@ -0,0 +1,26 @@
+package request
+
+import "mime/multipart"
+
+type ReqUploadForumImage struct {
+	ForumID uint                  `form:"forum_id" validate:"required"`
+	Image   *multipart.FileHeader `form:"image" validate:"required"`
+}
+
+type ReqUploadProfileImage struct {
+	Image *multipart.FileHeader `form:"image" validate:"required"`
+}
+
+type ReqUploadAttachment struct {
+	ForumID uint                  `form:"forum_id" validate:"required"`
+	File    *multipart.FileHeader `form:"file" validate:"required"`
+}
+
+type ReqGetFile struct {
+	Key string `uri:"key" validate:"required,max=255"`
+}
+
+type ReqGetAttachment struct {
+	ID        uint `form:"id" validate:"required"`
+	Thumbnail bool `form:"thumbnail"`
+}
@@ -1,6 +1,7 @@
 package request
 
 type ReqSaveUser struct {
+	Name     string `json:"name" validate:"required,max=255"`
 	NIM      string `json:"nim" validate:"req-numeric,max=10"`
 	Email    string `json:"email" validate:"req-email"`
 	Password string `json:"password" validate:"required"`
@@ -10,3 +11,34 @@ type ReqLoginUser struct {
 	User     string `json:"user" validate:"required"` // email or nim
 	Password string `json:"password" validate:"required"`
 }
+
+type ReqRefreshToken struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ReqUpdateProfile struct {
+	Name  string `json:"name" validate:"max=255"`
+	Prodi string `json:"prodi" validate:"max=255"`
+}
+
+type ReqDetailUser struct {
+	UserID uint `uri:"id" validate:"required"`
+}
+
+type ReqChangePassword struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ReqForgotPassword struct {
+	Email string `json:"email" validate:"req-email"`
+}
+
+type ReqResetPassword struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ReqSetUserStatus struct {
+	UserID uint `json:"user_id" validate:"required"`
+}
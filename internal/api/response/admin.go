@@ -0,0 +1,26 @@
+package response
+
+import "time"
+
+type ResAdminForum struct {
+	ID              uint       `json:"id"`
+	ForumName       string     `json:"forum_name"`
+	ForumImage      *string    `json:"forum_image"`
+	Category        *string    `json:"category"`
+	CreatedAt       time.Time  `json:"created_at"`
+	DeletedAt       *time.Time `json:"deleted_at"`
+	NumberOfMembers int64      `json:"number_of_members"`
+	NumberOfThreads int64      `json:"number_of_threads"`
+}
+
+type ResAdminStats struct {
+	TotalUsers    int64 `json:"total_users"`
+	InactiveUsers int64 `json:"inactive_users"`
+	TotalAdmins   int64 `json:"total_admins"`
+	TotalForums   int64 `json:"total_forums"`
+	DeletedForums int64 `json:"deleted_forums"`
+	TotalThreads  int64 `json:"total_threads"`
+	TotalReplies  int64 `json:"total_replies"`
+	TotalVotes    int64 `json:"total_votes"`
+	TotalLeaves   int64 `json:"total_leaves"`
+}
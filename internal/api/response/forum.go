@@ -1,6 +1,10 @@
 package response
 
-import "github.com/drdofx/talk-parmad/internal/api/models"
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/models"
+)
 
 type ResDetailForum struct {
 	ForumData       models.Forum            `json:"forum"`
@@ -33,11 +37,58 @@ type ResThreadForumHome struct {
 	ThreadID   uint   `json:"thread_id"`
 	Title      string `json:"title"`
 	Text       string `json:"text"`
+	TextHTML   string `json:"text_html" gorm:"-"`
 }
 
 type ResSearchForum struct {
-	ForumID    uint   `json:"id" gorm:"column:id"`
-	ForumName  string `json:"forum_name"`
-	ForumImage string `json:"forum_image"`
-	Category   string `json:"category"`
+	ForumID          uint   `json:"id" gorm:"column:id"`
+	ForumName        string `json:"forum_name"`
+	ForumImage       string `json:"forum_image"`
+	Category         string `json:"category"`
+	IntroductionText string `json:"introduction_text"`
+	NumberOfMembers  int64  `json:"number_of_members"`
+}
+
+type ResJoinRequest struct {
+	ID           uint      `json:"id"`
+	UserID       uint      `json:"user_id"`
+	UserName     string    `json:"user_name"`
+	NIM          *string   `json:"nim"`
+	ProfileImage *string   `json:"profile_image"`
+	Message      string    `json:"message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ResForumInvite carries the invite code, which is only ever shown once
+type ResForumInvite struct {
+	ID        uint       `json:"id"`
+	ForumID   uint       `json:"forum_id"`
+	Code      string     `json:"code"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses"`
+}
+
+type ResForumBan struct {
+	ID            uint       `json:"id"`
+	UserID        uint       `json:"user_id"`
+	UserName      string     `json:"user_name"`
+	ProfileImage  *string    `json:"profile_image"`
+	Reason        string     `json:"reason"`
+	BannedBy      uint       `json:"banned_by"`
+	BannedByName  string     `json:"banned_by_name"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	AppealID      *uint      `json:"appeal_id"`
+	AppealStatus  *string    `json:"appeal_status"`
+	AppealMessage *string    `json:"appeal_message"`
+}
+
+type ResModerator struct {
+	ID           uint      `json:"id"`
+	UserID       uint      `json:"user_id"`
+	UserName     string    `json:"user_name"`
+	ProfileImage *string   `json:"profile_image"`
+	Nickname     *string   `json:"nickname"`
+	Rank         string    `json:"rank"`
+	CreatedAt    time.Time `json:"created_at"`
 }
@@ -0,0 +1,8 @@
+package response
+
+import "github.com/drdofx/talk-parmad/internal/api/models"
+
+type ResListNotification struct {
+	Notifications []models.Notification `json:"notifications"`
+	UnreadCount   int64                 `json:"unread_count"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import "time"
+
+type ResSearchHit struct {
+	Type        string    `json:"type"`
+	ForumID     uint      `json:"forum_id"`
+	ForumName   string    `json:"forum_name"`
+	ThreadID    uint      `json:"thread_id"`
+	ThreadTitle string    `json:"thread_title"`
+	ReplyID     *uint     `json:"reply_id"`
+	Snippet     string    `json:"snippet"`
+	Score       float64   `json:"score"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package response
+
+import "time"
+
+type ResStreamThread struct {
+	ForumID   uint      `json:"forum_id"`
+	ThreadID  uint      `json:"thread_id"`
+	Title     string    `json:"title"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ResStreamReply struct {
+	ThreadID      uint      `json:"thread_id"`
+	ReplyID       uint      `json:"reply_id"`
+	ParentReplyID *uint     `json:"parent_reply_id"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ResStreamVotes struct {
+	ThreadID       uint  `json:"thread_id"`
+	ReplyID        *uint `json:"reply_id"`
+	TotalUpvotes   int   `json:"total_upvotes"`
+	TotalDownvotes int   `json:"total_downvotes"`
+}
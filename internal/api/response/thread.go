@@ -1,6 +1,10 @@
 package response
 
-import "github.com/drdofx/talk-parmad/internal/api/models"
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/models"
+)
 
 type ResDetailThread struct {
 	ThreadData     ResThreadField  `json:"thread"`
@@ -8,15 +12,23 @@ type ResDetailThread struct {
 	TotalReplies   int             `json:"total_replies"`
 	TotalUpvotes   int64           `json:"total_upvotes"`
 	TotalDownvotes int64           `json:"total_downvotes"`
+	UserVote       *bool           `json:"user_vote"`
 	CreatedBy      string          `json:"created_by"`
 }
 
 type ResListThread struct {
 	models.Thread
+	TextHTML   string `json:"text_html" gorm:"-"`
 	ForumName  string `json:"forum_name"`
 	ForumImage string `json:"forum_image"`
 }
 
+type ResMentionedThread struct {
+	ResListThread
+	MentionID   uint      `json:"-"`
+	MentionedAt time.Time `json:"mentioned_at"`
+}
+
 type ResListThreadReply struct {
 	models.Thread `json:"thread"`
 	models.Reply  `json:"reply"`
@@ -25,17 +37,26 @@ type ResListThreadReply struct {
 }
 
 type ResThreadField struct {
-	ID        uint   `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	CreatedAt string `json:"created_at"`
+	ID          uint                `json:"id"`
+	Title       string              `json:"title"`
+	Text        string              `json:"text"`
+	TextHTML    string              `json:"text_html"`
+	CreatedAt   string              `json:"created_at"`
+	Attachments []models.Attachment `json:"attachments"`
 }
 
 type ResReplyField struct {
-	ID             uint   `json:"id"`
-	Text           string `json:"text"`
-	CreatedBy      string `json:"created_by"`
-	CreatedAt      string `json:"created_at"`
-	TotalUpvotes   int64  `json:"total_upvotes"`
-	TotalDownvotes int64  `json:"total_downvotes"`
+	ID              uint                `json:"id"`
+	ParentReplyID   *uint               `json:"parent_reply_id"`
+	Text            string              `json:"text"`
+	TextHTML        string              `json:"text_html"`
+	CreatedBy       string              `json:"created_by"`
+	CreatedAt       string              `json:"created_at"`
+	TotalUpvotes    int64               `json:"total_upvotes"`
+	TotalDownvotes  int64               `json:"total_downvotes"`
+	UserVote        *bool               `json:"user_vote"`
+	Attachments     []models.Attachment `json:"attachments"`
+	Children        []ResReplyField     `json:"children"`
+	HasMoreChildren bool                `json:"has_more_children"`
+	ChildrenCursor  string              `json:"children_cursor,omitempty"`
 }
@@ -0,0 +1,6 @@
+package response
+
+type ResImage struct {
+	Image     string `json:"image"`
+	Thumbnail string `json:"thumbnail"`
+}
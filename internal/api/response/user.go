@@ -0,0 +1,27 @@
+package response
+
+import (
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/models"
+)
+
+type ResLoginUser struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ResUserProfile struct {
+	ID               uint           `json:"id"`
+	Name             string         `json:"name"`
+	Email            string         `json:"email,omitempty"`
+	NIM              *string        `json:"nim,omitempty"`
+	Role             string         `json:"role"`
+	Prodi            *string        `json:"prodi"`
+	ProfileImage     *string        `json:"profile_image"`
+	ProfileThumbnail *string        `json:"profile_thumbnail"`
+	CreatedAt        time.Time      `json:"created_at"`
+	Forums           []models.Forum `json:"forums"`
+	TotalThreads     int            `json:"total_threads"`
+	TotalReplies     int            `json:"total_replies"`
+}
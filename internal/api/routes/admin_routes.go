@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/controller"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
+)
+
+type AdminRoutes interface {
+	Route
+}
+
+type adminRoutes struct {
+	controller controller.AdminController
+	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
+}
+
+func NewAdminRoutes(controller controller.AdminController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) AdminRoutes {
+	return &adminRoutes{controller, handler, middleware}
+}
+
+func (r *adminRoutes) Setup() {
+	admin := r.handler.Gin.Group(constants.API_PATH + "/admin")
+	admin.Use(r.middleware.AuthorizeJWT(), r.middleware.RequireRole(constants.RoleAdmin))
+	{
+		admin.GET("/stats", r.controller.GetSiteStats)
+
+		user := admin.Group("/user")
+		{
+			user.GET("/list", r.controller.ListUsers)
+			user.PUT("/role", r.controller.SetUserRole)
+			user.PUT("/deactivate", r.controller.DeactivateUser)
+			user.PUT("/reactivate", r.controller.ReactivateUser)
+		}
+
+		forum := admin.Group("/forum")
+		{
+			forum.GET("/list", r.controller.ListForums)
+			forum.PUT("/restore", r.controller.RestoreForum)
+			forum.DELETE("/delete", r.controller.HardDeleteForum)
+		}
+	}
+}
@@ -14,24 +14,56 @@ type ForumRoutes interface {
 type forumRoutes struct {
 	controller controller.ForumController
 	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
 }
 
-func NewForumRoutes(controller controller.ForumController, handler *lib.RequestHandler) ForumRoutes {
-	return &forumRoutes{controller, handler}
+func NewForumRoutes(controller controller.ForumController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) ForumRoutes {
+	return &forumRoutes{controller, handler, middleware}
 }
 
 func (r *forumRoutes) Setup() {
-	auth := r.handler.Gin.Group(constants.API_PATH + "/forum").Use(middleware.AuthorizeJWT())
+	auth := r.handler.Gin.Group(constants.API_PATH + "/forum")
+	auth.Use(r.middleware.AuthorizeJWT())
 	{
 		auth.POST("/create", r.controller.CreateForum)
 		auth.POST("/join", r.controller.JoinForum)
+		auth.POST("/leave", r.controller.LeaveForum)
 		auth.PUT("/edit", r.controller.EditForum)
 		auth.DELETE("/delete", r.controller.DeleteForum)
+		auth.PUT("/restore", r.controller.RestoreForum)
 		auth.GET("/list", r.controller.ListUserForum)
 		auth.GET("/discover", r.controller.ListDiscoverForum)
 		auth.GET("/detail", r.controller.DetailForum)
 		auth.GET("/list-thread", r.controller.ListThreadForumHome)
 		auth.PUT("/remove", r.controller.RemoveFromForum)
 		auth.GET("/search", r.controller.SearchForum)
+
+		moderator := auth.Group("/moderator")
+		{
+			moderator.GET("/list", r.controller.ListModerators)
+			moderator.POST("/promote", r.controller.PromoteModerator)
+			moderator.PUT("/demote", r.controller.DemoteModerator)
+			moderator.PUT("/transfer", r.controller.TransferHeadModerator)
+		}
+
+		joinRequest := auth.Group("/join-request")
+		{
+			joinRequest.POST("/create", r.controller.RequestToJoin)
+			joinRequest.GET("/list", r.controller.ListJoinRequests)
+			joinRequest.PUT("/review", r.controller.ReviewJoinRequest)
+		}
+
+		invite := auth.Group("/invite")
+		{
+			invite.POST("/create", r.controller.CreateInvite)
+			invite.POST("/join", r.controller.JoinByInvite)
+		}
+
+		ban := auth.Group("/ban")
+		{
+			ban.GET("/list", r.controller.ListBans)
+			ban.POST("/appeal", r.controller.AppealBan)
+			ban.PUT("/appeal/review", r.controller.ReviewBanAppeal)
+		}
 	}
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/controller"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
+)
+
+type NotificationRoutes interface {
+	Route
+}
+
+type notificationRoutes struct {
+	controller controller.NotificationController
+	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
+}
+
+func NewNotificationRoutes(controller controller.NotificationController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) NotificationRoutes {
+	return &notificationRoutes{controller, handler, middleware}
+}
+
+func (r *notificationRoutes) Setup() {
+	auth := r.handler.Gin.Group(constants.API_PATH + "/notifications")
+	auth.Use(r.middleware.AuthorizeJWT())
+	{
+		auth.GET("/list", r.controller.ListNotifications)
+		auth.PUT("/read", r.controller.MarkRead)
+		auth.PUT("/read-all", r.controller.MarkAllRead)
+	}
+}
@@ -7,6 +7,11 @@ var Module = fx.Module("routes",
 		NewUserRoutes,
 		NewForumRoutes,
 		NewThreadRoutes,
+		NewAdminRoutes,
+		NewSearchRoutes,
+		NewNotificationRoutes,
+		NewStreamRoutes,
+		NewUploadRoutes,
 		NewRoutes,
 	),
 )
@@ -31,10 +36,20 @@ func NewRoutes(
 	userRoutes UserRoutes,
 	forumRoutes ForumRoutes,
 	threadRoutes ThreadRoutes,
+	adminRoutes AdminRoutes,
+	searchRoutes SearchRoutes,
+	notificationRoutes NotificationRoutes,
+	streamRoutes StreamRoutes,
+	uploadRoutes UploadRoutes,
 ) Routes {
 	return Routes{
 		userRoutes,
 		forumRoutes,
 		threadRoutes,
+		adminRoutes,
+		searchRoutes,
+		notificationRoutes,
+		streamRoutes,
+		uploadRoutes,
 	}
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/controller"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
+)
+
+type SearchRoutes interface {
+	Route
+}
+
+type searchRoutes struct {
+	controller controller.SearchController
+	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
+}
+
+func NewSearchRoutes(controller controller.SearchController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) SearchRoutes {
+	return &searchRoutes{controller, handler, middleware}
+}
+
+func (r *searchRoutes) Setup() {
+	r.handler.Gin.GET(constants.API_PATH+"/search", r.middleware.AuthorizeJWT(), r.controller.Search)
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/controller"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
+)
+
+type StreamRoutes interface {
+	Route
+}
+
+type streamRoutes struct {
+	controller controller.StreamController
+	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
+}
+
+func NewStreamRoutes(controller controller.StreamController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) StreamRoutes {
+	return &streamRoutes{controller, handler, middleware}
+}
+
+func (r *streamRoutes) Setup() {
+	r.handler.Gin.GET(constants.API_PATH+"/stream", r.middleware.AuthorizeStream(), r.controller.Stream)
+}
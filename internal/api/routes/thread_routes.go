@@ -14,29 +14,35 @@ type ThreadRoutes interface {
 type threadRoutes struct {
 	controller controller.ThreadController
 	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
 }
 
-func NewThreadRoutes(controller controller.ThreadController, handler *lib.RequestHandler) ThreadRoutes {
-	return &threadRoutes{controller, handler}
+func NewThreadRoutes(controller controller.ThreadController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) ThreadRoutes {
+	return &threadRoutes{controller, handler, middleware}
 }
 
 func (r *threadRoutes) Setup() {
 	auth := r.handler.Gin.Group(constants.API_PATH + "/thread")
-	auth.Use(middleware.AuthorizeJWT())
+	auth.Use(r.middleware.AuthorizeJWT())
 	{
 		auth.POST("/create", r.controller.CreateThread)
 		auth.POST("/vote", r.controller.VoteThread)
+		auth.DELETE("/vote", r.controller.RetractVoteThread)
 		auth.PUT("/edit", r.controller.EditThread)
 		auth.GET("/detail", r.controller.DetailThread)
 		auth.GET("/list", r.controller.ListUserThread)
+		auth.GET("/mentioned", r.controller.ListMentionedThreads)
 		auth.DELETE("/delete", r.controller.DeleteThread)
+		auth.PUT("/restore", r.controller.RestoreThread)
 
 		reply := auth.Group("/reply")
 		{
 			reply.POST("/create", r.controller.CreateReply)
 			reply.POST("/vote", r.controller.VoteReply)
+			reply.DELETE("/vote", r.controller.RetractVoteReply)
 			reply.PUT("/edit", r.controller.EditReply)
 			reply.GET("/list", r.controller.ListUserReply)
+			reply.GET("/children", r.controller.ListReplyChildren)
 			reply.DELETE("/delete", r.controller.DeleteReply)
 		}
 	}
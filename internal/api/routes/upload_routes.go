@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/controller"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
+)
+
+type UploadRoutes interface {
+	Route
+}
+
+type uploadRoutes struct {
+	controller controller.UploadController
+	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
+}
+
+func NewUploadRoutes(controller controller.UploadController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) UploadRoutes {
+	return &uploadRoutes{controller, handler, middleware}
+}
+
+func (r *uploadRoutes) Setup() {
+	r.handler.Gin.POST(constants.API_PATH+"/forum/image", r.middleware.AuthorizeJWT(), r.controller.UploadForumImage)
+	r.handler.Gin.PUT(constants.API_PATH+"/user/me/image", r.middleware.AuthorizeJWT(), r.controller.UploadProfileImage)
+	r.handler.Gin.POST(constants.AttachmentPath, r.middleware.AuthorizeJWT(), r.controller.UploadAttachment)
+	r.handler.Gin.GET(constants.AttachmentPath, r.middleware.AuthorizeJWT(), r.controller.GetAttachment)
+
+	// Forum and profile images are public so they can be used in <img>
+	// tags, their names can't be guessed. Attachments are not served here.
+	r.handler.Gin.GET(constants.API_PATH+"/files/*key", r.controller.GetFile)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/drdofx/talk-parmad/internal/api/constants"
 	"github.com/drdofx/talk-parmad/internal/api/controller"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/middleware"
 )
 
 type UserRoutes interface {
@@ -13,10 +14,11 @@ type UserRoutes interface {
 type userRoutes struct {
 	controller controller.UserController
 	handler    *lib.RequestHandler
+	middleware *middleware.AuthMiddleware
 }
 
-func NewUserRoutes(controller controller.UserController, handler *lib.RequestHandler) UserRoutes {
-	return &userRoutes{controller, handler}
+func NewUserRoutes(controller controller.UserController, handler *lib.RequestHandler, middleware *middleware.AuthMiddleware) UserRoutes {
+	return &userRoutes{controller, handler, middleware}
 }
 
 func (r *userRoutes) Setup() {
@@ -24,5 +26,17 @@ func (r *userRoutes) Setup() {
 	{
 		auth.POST("/login", r.controller.LoginUser)
 		auth.POST("/register", r.controller.CreateUser)
+		auth.POST("/refresh", r.controller.RefreshToken)
+		auth.POST("/logout", r.middleware.AuthorizeJWT(), r.controller.LogoutUser)
+		auth.POST("/password/forgot", r.controller.ForgotPassword)
+		auth.POST("/password/reset", r.controller.ResetPassword)
+	}
+
+	user := r.handler.Gin.Group(constants.API_PATH + "/user").Use(r.middleware.AuthorizeJWT())
+	{
+		user.GET("/me", r.controller.DetailMe)
+		user.PUT("/me", r.controller.UpdateProfile)
+		user.PUT("/password", r.controller.ChangePassword)
+		user.GET("/:id", r.controller.DetailUser)
 	}
 }
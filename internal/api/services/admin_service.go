@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
+)
+
+type AdminService interface {
+	ListUsers(req *request.ReqAdminListUser) ([]models.User, *response.ResPagination, error)
+	SetUserRole(req *request.ReqAdminSetRole, admin *lib.UserData) error
+	ListForums(req *request.ReqPagination) ([]response.ResAdminForum, *response.ResPagination, error)
+	RestoreForum(req *request.ReqAdminForum) error
+	HardDeleteForum(req *request.ReqAdminForum) error
+	GetSiteStats() (*response.ResAdminStats, error)
+}
+
+type adminService struct {
+	repository      repository.AdminRepository
+	userRepo        repository.UserRepository
+	sessionRepo     repository.SessionRepository
+	forumRepo       repository.ForumRepository
+	transactionRepo repository.TransactionRepository
+	searchIndex     repository.SearchIndex
+	blobStore       lib.BlobStore
+}
+
+func NewAdminService(
+	repository repository.AdminRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	forumRepo repository.ForumRepository,
+	transactionRepo repository.TransactionRepository,
+	searchIndex repository.SearchIndex,
+	blobStore lib.BlobStore,
+) AdminService {
+	return &adminService{repository, userRepo, sessionRepo, forumRepo, transactionRepo, searchIndex, blobStore}
+}
+
+func (s *adminService) ListUsers(req *request.ReqAdminListUser) ([]models.User, *response.ResPagination, error) {
+	// Get a page of the users matching the filters
+	users, pagination, err := s.userRepo.SearchUsers(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return users, pagination, nil
+}
+
+func (s *adminService) SetUserRole(req *request.ReqAdminSetRole, admin *lib.UserData) error {
+	if req.UserID == admin.UserID {
+		return fmt.Errorf(helper.CannotChangeSelf)
+	}
+
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		userRepo := s.userRepo.WithTx(tx)
+
+		// Get the user data by id
+		user, err := userRepo.GetUserByID(req.UserID)
+		if err != nil {
+			return err
+		}
+
+		if user.Role == req.Role {
+			return nil
+		}
+
+		if err := userRepo.UpdateRole(user, req.Role); err != nil {
+			return err
+		}
+
+		// The role is part of the access token, so sign the user out to
+		// make the new role apply
+		return s.sessionRepo.WithTx(tx).RevokeUserSessions(user.ID)
+	})
+}
+
+func (s *adminService) ListForums(req *request.ReqPagination) ([]response.ResAdminForum, *response.ResPagination, error) {
+	// Get a page of the forums, including the deleted ones
+	forums, pagination, err := s.repository.ListAllForums(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return forums, pagination, nil
+}
+
+func (s *adminService) RestoreForum(req *request.ReqAdminForum) error {
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the forum by id, including the deleted ones
+		forum, err := repo.GetForumByIDUnscoped(req.ForumID)
+		if err != nil {
+			return err
+		}
+
+		if !forum.DeletedAt.Valid {
+			return fmt.Errorf(helper.ForumNotDeleted)
+		}
+
+		return repo.RestoreForum(forum)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	reindexForum(s.forumRepo, s.searchIndex, req.ForumID)
+
+	return nil
+}
+
+func (s *adminService) HardDeleteForum(req *request.ReqAdminForum) error {
+	var files []*string
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the forum by id, including the deleted ones
+		forum, err := repo.GetForumByIDUnscoped(req.ForumID)
+		if err != nil {
+			return err
+		}
+
+		// Collect the stored files before the rows pointing to them are gone
+		attachments, err := repo.ListForumAttachments(forum.ID)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, forum.ForumImage, forum.ForumThumbnail)
+		for i := range attachments {
+			files = append(files, &attachments[i].URL, attachments[i].ThumbnailURL)
+		}
+
+		// Permanently delete the forum and its content
+		return repo.HardDeleteForum(forum)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.searchIndex.RemoveForum(req.ForumID); err != nil {
+		lib.CommonLogger().Error(err)
+	}
+
+	// Only delete the files once the deletion is committed
+	deleteStoredFiles(s.blobStore, files...)
+
+	return nil
+}
+
+func (s *adminService) GetSiteStats() (*response.ResAdminStats, error) {
+	stats, err := s.repository.GetSiteStats()
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -2,270 +2,872 @@ package services
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/events"
 	"github.com/drdofx/talk-parmad/internal/api/helper"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
 	"github.com/drdofx/talk-parmad/internal/api/models"
 	"github.com/drdofx/talk-parmad/internal/api/repository"
 	"github.com/drdofx/talk-parmad/internal/api/request"
 	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
 )
 
 type ForumService interface {
 	CreateForum(req *request.ReqSaveForum, user *lib.UserData) (*models.Forum, error)
 	JoinForum(req *request.ReqJoinForum, user *lib.UserData) error
-	CheckModeratorForum(req *request.ReqCheckModeratorForum) (bool, error)
-	EditForum(req *request.ReqEditForum) (*models.Forum, error)
-	DeleteForum(req *request.ReqDeleteForum) error
-	ListUserForum(user *lib.UserData) ([]models.Forum, error)
-	ListThreadForumHome(user *lib.UserData) (*[]response.ResThreadForumHome, error)
-	DiscoverForum(user *lib.UserData) ([]models.Forum, error)
-	DetailForum(user *lib.UserData, req *request.ReqDetailForum) (*response.ResDetailForum, error)
-	RemoveFromForum(req *request.ReqRemoveFromForum) error
-	SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, error)
+	RequestToJoin(req *request.ReqRequestToJoin, user *lib.UserData) (*models.JoinRequest, error)
+	ListJoinRequests(req *request.ReqListJoinRequest, user *lib.UserData) ([]response.ResJoinRequest, *response.ResPagination, error)
+	ReviewJoinRequest(req *request.ReqReviewJoinRequest, user *lib.UserData) error
+	CreateInvite(req *request.ReqCreateInvite, user *lib.UserData) (*response.ResForumInvite, error)
+	JoinByInvite(req *request.ReqJoinByInvite, user *lib.UserData) (*models.Forum, error)
+	LeaveForum(req *request.ReqLeaveForum, user *lib.UserData) error
+	EditForum(req *request.ReqEditForum, user *lib.UserData) (*models.Forum, error)
+	DeleteForum(req *request.ReqDeleteForum, user *lib.UserData) error
+	RestoreForum(req *request.ReqRestoreForum, user *lib.UserData) error
+	ListUserForum(user *lib.UserData, req *request.ReqPagination) ([]models.Forum, *response.ResPagination, error)
+	ListThreadForumHome(user *lib.UserData, req *request.ReqPagination) (*[]response.ResThreadForumHome, *response.ResPagination, error)
+	DiscoverForum(user *lib.UserData, req *request.ReqPagination) ([]models.Forum, *response.ResPagination, error)
+	DetailForum(user *lib.UserData, req *request.ReqDetailForum) (*response.ResDetailForum, *response.ResPagination, error)
+	RemoveFromForum(req *request.ReqRemoveFromForum, user *lib.UserData) error
+	SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, *response.ResPagination, error)
+	ListModerators(req *request.ReqListModerator, user *lib.UserData) ([]response.ResModerator, error)
+	PromoteModerator(req *request.ReqPromoteModerator, user *lib.UserData) (*models.Moderator, error)
+	DemoteModerator(req *request.ReqDemoteModerator, user *lib.UserData) error
+	TransferHeadModerator(req *request.ReqTransferHeadModerator, user *lib.UserData) error
+	ListBans(req *request.ReqListBan, user *lib.UserData) ([]response.ResForumBan, *response.ResPagination, error)
+	AppealBan(req *request.ReqAppealBan, user *lib.UserData) (*models.BanAppeal, error)
+	ReviewBanAppeal(req *request.ReqReviewBanAppeal, user *lib.UserData) error
 	// ReadById(id uint) (*models.Forum, error)
-	// ExitForum(req *request.ReqExitForum) (*models.Forum, error)
 }
 
 type forumService struct {
 	repository      repository.ForumRepository
+	banRepo         repository.BanRepository
+	membershipRepo  repository.MembershipRepository
 	transactionRepo repository.TransactionRepository
+	searchIndex     repository.SearchIndex
+	bus             events.Bus
 }
 
-func NewForumService(repo repository.ForumRepository, transactionRepo repository.TransactionRepository) ForumService {
-	return &forumService{repo, transactionRepo}
+func NewForumService(
+	repo repository.ForumRepository,
+	banRepo repository.BanRepository,
+	membershipRepo repository.MembershipRepository,
+	transactionRepo repository.TransactionRepository,
+	searchIndex repository.SearchIndex,
+	bus events.Bus,
+) ForumService {
+	return &forumService{repo, banRepo, membershipRepo, transactionRepo, searchIndex, bus}
 }
 
 func (s *forumService) CreateForum(req *request.ReqSaveForum, user *lib.UserData) (*models.Forum, error) {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
-
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
-		}
-	}()
-
 	// Check if user is authorized to create a forum
-	if user.Role != "User" {
+	if user.Role != constants.RoleUser && user.Role != constants.RoleAdmin {
 		return nil, fmt.Errorf(helper.RoleNotAuthorized)
 	}
 
-	// Check if forum with the same name already exists
-	existingForum, _ := s.repository.GetForumByName(req.ForumName)
-	if existingForum != nil {
-		return nil, fmt.Errorf(helper.ForumExists)
-	}
+	var createdForum *models.Forum
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Check if forum with the same name already exists
+		existingForum, _ := repo.GetForumByName(req.ForumName)
+		if existingForum != nil {
+			return fmt.Errorf(helper.ForumExists)
+		}
+
+		forum, err := repo.CreateForum(req, user)
+		if err != nil {
+			return err
+		}
+
+		// Create the moderator (head) for the forum
+		_, err = repo.CreateModeratorHead(forum, user)
+		if err != nil {
+			return err
+		}
+
+		// Create the user-forum relation
+		_, err = repo.CreateUserForum(forum, user.UserID)
+		if err != nil {
+			return err
+		}
+
+		createdForum = forum
+		return nil
+	})
 
-	createdForum, err := s.repository.CreateForum(req, user)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create the moderator (head) for the forum
-	_, err = s.repository.CreateModeratorHead(createdForum, user)
+	return createdForum, nil
+}
+
+func (s *forumService) JoinForum(req *request.ReqJoinForum, user *lib.UserData) error {
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the forum by id
+		forum, err := repo.GetForumById(req.ForumID)
+		if err != nil {
+			return err
+		}
+
+		// Only public forums can be joined directly
+		switch forum.Visibility {
+		case constants.ForumVisibilityRequestToJoin:
+			return fmt.Errorf(helper.JoinRequestRequired)
+		case constants.ForumVisibilityInviteOnly:
+			return fmt.Errorf(helper.InviteRequired)
+		}
+
+		if err := s.checkCanJoin(tx, forum.ID, user.UserID); err != nil {
+			return err
+		}
+
+		// Create the user-forum relation
+		_, err = repo.CreateUserForum(forum, user.UserID)
+		return err
+	})
+}
+
+func (s *forumService) RequestToJoin(req *request.ReqRequestToJoin, user *lib.UserData) (*models.JoinRequest, error) {
+	var joinRequest *models.JoinRequest
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		membershipRepo := s.membershipRepo.WithTx(tx)
+
+		// Get the forum by id
+		forum, err := s.repository.WithTx(tx).GetForumById(req.ForumID)
+		if err != nil {
+			return err
+		}
+
+		if forum.Visibility != constants.ForumVisibilityRequestToJoin {
+			return fmt.Errorf(helper.ForumNotRequestToJoin)
+		}
+
+		if err := s.checkCanJoin(tx, forum.ID, user.UserID); err != nil {
+			return err
+		}
+
+		// One request per user may wait for review at a time
+		pending, _ := membershipRepo.GetPendingJoinRequest(forum.ID, user.UserID)
+		if pending != nil {
+			return fmt.Errorf(helper.JoinAlreadyRequested)
+		}
+
+		joinRequest = &models.JoinRequest{
+			ForumID: forum.ID,
+			UserID:  user.UserID,
+			Message: req.Message,
+			Status:  constants.JoinRequestPending,
+		}
+
+		return membershipRepo.CreateJoinRequest(joinRequest)
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	// Create the user-forum relation
-	_, err = s.repository.CreateUserForum(createdForum, user)
-	if err != nil {
-		return nil, err
+	return joinRequest, nil
+}
+
+func (s *forumService) ListJoinRequests(req *request.ReqListJoinRequest, user *lib.UserData) ([]response.ResJoinRequest, *response.ResPagination, error) {
+	if _, err := authorizeModeration(s.repository, req.ForumID, user, ActionManageMembers); err != nil {
+		return nil, nil, err
 	}
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+	// Get the requests waiting for review, oldest first
+	joinRequests, pagination, err := s.membershipRepo.ListPendingJoinRequests(req.ForumID, &req.ReqPagination)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return createdForum, nil
+	return joinRequests, pagination, nil
 }
 
-func (s *forumService) JoinForum(req *request.ReqJoinForum, user *lib.UserData) error {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
+func (s *forumService) ReviewJoinRequest(req *request.ReqReviewJoinRequest, user *lib.UserData) error {
+	var joinRequest *models.JoinRequest
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		membershipRepo := s.membershipRepo.WithTx(tx)
+
+		// Get the join request by id
+		joinRequest, _ = membershipRepo.GetJoinRequestByID(req.RequestID)
+		if joinRequest == nil {
+			return fmt.Errorf(helper.JoinRequestNotFound)
+		}
+
+		if _, err := authorizeModeration(repo, joinRequest.ForumID, user, ActionManageMembers); err != nil {
+			return err
+		}
 
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+		if joinRequest.Status != constants.JoinRequestPending {
+			return fmt.Errorf(helper.JoinRequestNotPending)
 		}
-	}()
 
-	// Get the forum by id
-	forum, err := s.repository.GetForumById(req.ForumID)
+		if err := membershipRepo.ReviewJoinRequest(joinRequest, req.Status, user.UserID); err != nil {
+			return err
+		}
+
+		if req.Status == constants.JoinRequestRejected {
+			return nil
+		}
+
+		// Get the forum by id
+		forum, err := repo.GetForumById(joinRequest.ForumID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkCanJoin(tx, forum.ID, joinRequest.UserID); err != nil {
+			return err
+		}
+
+		// Create the user-forum relation
+		_, err = repo.CreateUserForum(forum, joinRequest.UserID)
+		return err
+	})
+
 	if err != nil {
 		return err
 	}
 
-	// Check if user is already a member of the forum
-	userForum, _ := s.repository.GetUserForumByID(forum.ID, user.UserID)
-	if userForum != nil {
-		return fmt.Errorf(helper.UserAlreadyMember)
+	s.bus.Publish(events.JoinRequestReviewed{Actor: *user, ForumID: joinRequest.ForumID, UserID: joinRequest.UserID, Status: req.Status})
+
+	return nil
+}
+
+func (s *forumService) CreateInvite(req *request.ReqCreateInvite, user *lib.UserData) (*response.ResForumInvite, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf(helper.InviteExpiryInPast)
 	}
 
-	// Create the user-forum relation
-	_, err = s.repository.CreateUserForum(forum, user)
+	if _, err := authorizeModeration(s.repository, req.ForumID, user, ActionManageMembers); err != nil {
+		return nil, err
+	}
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+	// Only the hash of the code is stored, the code itself is shown once
+	code, codeHash, err := lib.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf(helper.FailedGenerateToken)
+	}
+
+	invite := &models.ForumInvite{
+		ForumID:   req.ForumID,
+		CodeHash:  codeHash,
+		CreatedBy: user.UserID,
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+	}
 
-	return err
+	if err := s.membershipRepo.CreateInvite(invite); err != nil {
+		return nil, err
+	}
+
+	return &response.ResForumInvite{
+		ID:        invite.ID,
+		ForumID:   invite.ForumID,
+		Code:      code,
+		ExpiresAt: invite.ExpiresAt,
+		MaxUses:   invite.MaxUses,
+	}, nil
 }
 
-func (s *forumService) CheckModeratorForum(req *request.ReqCheckModeratorForum) (bool, error) {
-	// Get the forum by id
-	forum, err := s.repository.GetForumById(req.ForumID)
+func (s *forumService) JoinByInvite(req *request.ReqJoinByInvite, user *lib.UserData) (*models.Forum, error) {
+	var joinedForum *models.Forum
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		membershipRepo := s.membershipRepo.WithTx(tx)
+
+		// Get the invite by its code
+		invite, _ := membershipRepo.GetInviteByCodeHash(lib.HashOpaqueToken(req.Code))
+		if invite == nil {
+			return fmt.Errorf(helper.InviteInvalid)
+		}
+
+		// Get the forum by id
+		forum, err := repo.GetForumById(invite.ForumID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkCanJoin(tx, forum.ID, user.UserID); err != nil {
+			return err
+		}
+
+		if err := membershipRepo.UseInvite(invite); err != nil {
+			return err
+		}
+
+		// Create the user-forum relation
+		joinedForum = forum
+		_, err = repo.CreateUserForum(forum, user.UserID)
+		return err
+	})
+
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	return joinedForum, nil
+}
+
+// checkCanJoin checks that the user is neither a member of the forum nor
+// banned from it
+func (s *forumService) checkCanJoin(tx *gorm.DB, forumID uint, userID uint) error {
+	// Check if user is already a member of the forum
+	userForum, _ := s.repository.WithTx(tx).GetUserForumByID(forumID, userID)
+	if userForum != nil {
+		return fmt.Errorf(helper.UserAlreadyMember)
 	}
 
-	// Check if user is a moderator of the forum
-	moderator, _ := s.repository.GetModeratorByID(forum.ID, req.UserID)
-	if moderator == nil {
-		return false, fmt.Errorf(helper.UserNotModerator)
+	// Check if user is still banned from the forum
+	ban, _ := s.banRepo.WithTx(tx).GetActiveBan(forumID, userID)
+	if ban != nil {
+		return fmt.Errorf(helper.UserBanned)
 	}
 
-	return true, nil
+	return nil
+}
+
+func (s *forumService) LeaveForum(req *request.ReqLeaveForum, user *lib.UserData) error {
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Check if user is a member of the forum
+		userForum, _ := repo.GetUserForumByID(req.ForumID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// The head has to hand over the role before leaving, other
+		// moderators lose theirs
+		moderator, _ := repo.GetModeratorByID(req.ForumID, user.UserID)
+		if moderator != nil {
+			if moderator.Rank == constants.ModeratorRankHead {
+				return fmt.Errorf(helper.CannotLeaveAsHead)
+			}
+
+			if err := repo.DeleteModerator(moderator); err != nil {
+				return err
+			}
+		}
+
+		return repo.LeaveForum(userForum)
+	})
 }
 
-func (s *forumService) ListUserForum(user *lib.UserData) ([]models.Forum, error) {
+func (s *forumService) ListUserForum(user *lib.UserData, req *request.ReqPagination) ([]models.Forum, *response.ResPagination, error) {
 	// Get the list of forums
-	forums, err := s.repository.ListUserForum(user)
+	forums, pagination, err := s.repository.ListUserForum(user, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return forums, nil
+	return forums, pagination, nil
 }
 
-func (s *forumService) DiscoverForum(user *lib.UserData) ([]models.Forum, error) {
+func (s *forumService) DiscoverForum(user *lib.UserData, req *request.ReqPagination) ([]models.Forum, *response.ResPagination, error) {
 	// Get the list of not joined forums
-	forums, err := s.repository.DiscoverForum(user)
+	forums, pagination, err := s.repository.DiscoverForum(user, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return forums, nil
+	return forums, pagination, nil
 }
 
-func (s *forumService) DetailForum(user *lib.UserData, req *request.ReqDetailForum) (*response.ResDetailForum, error) {
-	// Get the forum detail, including the list of threads
-	forum, err := s.repository.DetailForum(user, req.ForumID)
+func (s *forumService) DetailForum(user *lib.UserData, req *request.ReqDetailForum) (*response.ResDetailForum, *response.ResPagination, error) {
+	// Users who may not read the forum only get to see what it is about
+	found, err := authorizeForumRead(s.repository, req.ForumID, user)
+	if found == nil {
+		return nil, nil, err
+	}
+
 	if err != nil {
-		return nil, err
+		members, err := s.repository.CountMembers(found.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &response.ResDetailForum{ForumData: *found, NumberOfMembers: members}, nil, nil
 	}
 
 	// check if user is a member of the forum
 	userForum, _ := s.repository.GetUserForumByID(req.ForumID, user.UserID)
-	if userForum == nil {
-		forum.IsMember = false
-	} else {
-		forum.IsMember = true
+
+	// Get the forum detail, including a page of its threads
+	forum, pagination, err := s.repository.DetailForum(user, req.ForumID, &req.ReqPagination)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return forum, nil
+	forum.IsMember = userForum != nil
 
+	return forum, pagination, nil
 }
 
-func (s *forumService) ListThreadForumHome(user *lib.UserData) (*[]response.ResThreadForumHome, error) {
+func (s *forumService) ListThreadForumHome(user *lib.UserData, req *request.ReqPagination) (*[]response.ResThreadForumHome, *response.ResPagination, error) {
 	// Get the list of threads
-	threads, err := s.repository.ListThreadForumHome(user.UserID)
+	threads, pagination, err := s.repository.ListThreadForumHome(user.UserID, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	for i := range *threads {
+		(*threads)[i].TextHTML = lib.RenderMarkdown((*threads)[i].Text)
 	}
 
-	return threads, nil
+	return threads, pagination, nil
 }
 
-func (s *forumService) EditForum(req *request.ReqEditForum) (*models.Forum, error) {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
+func (s *forumService) EditForum(req *request.ReqEditForum, user *lib.UserData) (*models.Forum, error) {
+	var updatedForum *models.Forum
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the forum by id
+		forum, err := repo.GetForumById(req.ForumID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := authorizeModeration(repo, forum.ID, user, ActionEditForum); err != nil {
+			return err
+		}
+
+		if req.AttachmentTypes != "" {
+			types, err := normalizeAttachmentTypes(req.AttachmentTypes)
+			if err != nil {
+				return err
+			}
 
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+			req.AttachmentTypes = types
 		}
-	}()
 
-	// Get the forum by id
-	forum, err := s.repository.GetForumById(req.ForumID)
+		// Update the forum
+		updatedForum, err = repo.UpdateForum(forum, req)
+		return err
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	// Update the forum
-	updatedForum, err := s.repository.UpdateForum(forum, req)
+	if err := s.searchIndex.RenameForum(updatedForum.ID, updatedForum.ForumName); err != nil {
+		lib.CommonLogger().Error(err)
+	}
+
+	return updatedForum, nil
+}
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+func (s *forumService) DeleteForum(req *request.ReqDeleteForum, user *lib.UserData) error {
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the forum by id
+		forum, err := repo.GetForumById(req.ForumID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := authorizeModeration(repo, forum.ID, user, ActionDeleteForum); err != nil {
+			return err
+		}
 
-	return updatedForum, err
+		// Delete the forum
+		return repo.DeleteForum(forum)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.searchIndex.RemoveForum(req.ForumID); err != nil {
+		lib.CommonLogger().Error(err)
+	}
+
+	return nil
 }
 
-func (s *forumService) DeleteForum(req *request.ReqDeleteForum) error {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
+func (s *forumService) RestoreForum(req *request.ReqRestoreForum, user *lib.UserData) error {
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
 
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+		// Get the deleted forum by id
+		forum, _ := repo.GetDeletedForumByID(req.ForumID)
+		if forum == nil {
+			return fmt.Errorf(helper.ForumNotDeleted)
 		}
-	}()
 
-	// Get the forum by id
-	forum, err := s.repository.GetForumById(req.ForumID)
+		// The moderators were deleted with the forum, so the user is checked
+		// against their row from the deletion
+		if _, err := authorizeDeletedForumModeration(repo, forum, user, ActionDeleteForum); err != nil {
+			return err
+		}
+
+		// Bring back everything the deletion removed
+		return repo.RestoreForum(forum)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	reindexForum(s.repository, s.searchIndex, req.ForumID)
+
+	return nil
+}
+
+func (s *forumService) RemoveFromForum(req *request.ReqRemoveFromForum, user *lib.UserData) error {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf(helper.BanExpiryInPast)
+	}
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		if _, err := authorizeModeration(repo, req.ForumID, user, ActionRemoveMember); err != nil {
+			return err
+		}
+
+		// Check if user is indeed a member of the forum
+		userForum, _ := repo.GetUserForumByID(req.ForumID, req.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// Removing a moderator also takes away their moderator role
+		target, _ := repo.GetModeratorByID(req.ForumID, req.UserID)
+		if target != nil {
+			if target.Rank == constants.ModeratorRankHead {
+				return fmt.Errorf(helper.CannotRemoveHead)
+			}
+
+			if _, err := authorizeModerationOf(repo, req.ForumID, user, ActionRemoveModerator, req.UserID); err != nil {
+				return err
+			}
+
+			if err := repo.DeleteModerator(target); err != nil {
+				return err
+			}
+		}
+
+		// Delete the user-forum relation
+		if err := repo.RemoveFromForum(userForum); err != nil {
+			return err
+		}
+
+		// Ban the user so they can't rejoin right away
+		return s.banRepo.WithTx(tx).CreateBan(&models.ForumBan{
+			ForumID:   req.ForumID,
+			UserID:    req.UserID,
+			BannedBy:  user.UserID,
+			Reason:    req.Reason,
+			ExpiresAt: req.ExpiresAt,
+		})
+	})
+
 	if err != nil {
 		return err
 	}
 
-	// Delete the forum
-	err = s.repository.DeleteForum(forum)
+	s.bus.Publish(events.MemberRemoved{Actor: *user, ForumID: req.ForumID, UserID: req.UserID})
+
+	return nil
+}
+
+func (s *forumService) SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, *response.ResPagination, error) {
+	// Get the list of forums
+	forums, pagination, err := s.repository.SearchForum(req)
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return err
+	return forums, pagination, nil
 }
 
-func (s *forumService) RemoveFromForum(req *request.ReqRemoveFromForum) error {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
+func (s *forumService) ListModerators(req *request.ReqListModerator, user *lib.UserData) ([]response.ResModerator, error) {
+	// Only users who may read the forum get to see who moderates it
+	forum, err := authorizeForumRead(s.repository, req.ForumID, user)
+	if err != nil {
+		return nil, err
+	}
 
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+	// Get the list of moderators, head first
+	moderators, err := s.repository.ListModerators(forum.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return moderators, nil
+}
+
+func (s *forumService) PromoteModerator(req *request.ReqPromoteModerator, user *lib.UserData) (*models.Moderator, error) {
+	var moderator *models.Moderator
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		if _, err := authorizeModeration(repo, req.ForumID, user, ActionManageModerators); err != nil {
+			return err
+		}
+
+		// Check if user is a member of the forum
+		userForum, _ := repo.GetUserForumByID(req.ForumID, req.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
 		}
-	}()
 
-	// Check if user is indeed a member of the forum
-	userForum, _ := s.repository.GetUserForumByID(req.ForumID, req.UserID)
-	if userForum == nil {
-		return fmt.Errorf(helper.UserNotMember)
+		// Check if user is already a moderator of the forum
+		existing, _ := repo.GetModeratorByID(req.ForumID, req.UserID)
+		if existing != nil {
+			return fmt.Errorf(helper.UserAlreadyModerator)
+		}
+
+		var nickname *string
+		if req.Nickname != "" {
+			nickname = &req.Nickname
+		}
+
+		var err error
+		moderator, err = repo.CreateModerator(req.ForumID, req.UserID, constants.ModeratorRankMember, nickname)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	// Delete the user-forum relation
-	err := s.repository.RemoveFromForum(userForum)
+	s.bus.Publish(events.ModeratorPromoted{Actor: *user, ForumID: req.ForumID, UserID: req.UserID})
+
+	return moderator, nil
+}
+
+func (s *forumService) DemoteModerator(req *request.ReqDemoteModerator, user *lib.UserData) error {
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		if _, err := authorizeModeration(repo, req.ForumID, user, ActionManageModerators); err != nil {
+			return err
+		}
+
+		// The head has to hand over the role before stepping down
+		if req.UserID == user.UserID {
+			return fmt.Errorf(helper.CannotDemoteHead)
+		}
+
+		// Check if user is a moderator of the forum
+		moderator, _ := repo.GetModeratorByID(req.ForumID, req.UserID)
+		if moderator == nil {
+			return fmt.Errorf(helper.UserNotModerator)
+		}
+
+		return repo.DeleteModerator(moderator)
+	})
+
 	if err != nil {
 		return err
 	}
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+	s.bus.Publish(events.ModeratorDemoted{Actor: *user, ForumID: req.ForumID, UserID: req.UserID})
 
 	return nil
 }
 
-func (s *forumService) SearchForum(req *request.ReqSearchForum) (*[]response.ResSearchForum, error) {
-	// Get the list of forums
-	forums, err := s.repository.SearchForum(req)
+func (s *forumService) TransferHeadModerator(req *request.ReqTransferHeadModerator, user *lib.UserData) error {
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		if _, err := authorizeModeration(repo, req.ForumID, user, ActionManageModerators); err != nil {
+			return err
+		}
+
+		// Lock the head row so concurrent transfers cannot leave two heads
+		head, err := repo.GetHeadModerator(req.ForumID)
+		if err != nil || head.UserID != user.UserID {
+			return fmt.Errorf(helper.UserNotHeadModerator)
+		}
+
+		if req.UserID == user.UserID {
+			return fmt.Errorf(helper.CannotTargetSelf)
+		}
+
+		// Check if user is a member of the forum
+		userForum, _ := repo.GetUserForumByID(req.ForumID, req.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// Step the current head down first so the forum never has two heads
+		if err := repo.UpdateModeratorRank(head, constants.ModeratorRankMember); err != nil {
+			return err
+		}
+
+		// Promote the user, who may or may not be a moderator already
+		moderator, _ := repo.GetModeratorByID(req.ForumID, req.UserID)
+		if moderator == nil {
+			_, err = repo.CreateModerator(req.ForumID, req.UserID, constants.ModeratorRankHead, nil)
+			return err
+		}
+
+		return repo.UpdateModeratorRank(moderator, constants.ModeratorRankHead)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.HeadModeratorTransferred{Actor: *user, ForumID: req.ForumID, UserID: req.UserID})
+
+	return nil
+}
+
+func (s *forumService) ListBans(req *request.ReqListBan, user *lib.UserData) ([]response.ResForumBan, *response.ResPagination, error) {
+	if _, err := authorizeModeration(s.repository, req.ForumID, user, ActionManageBans); err != nil {
+		return nil, nil, err
+	}
+
+	// Get the bans in force, with their latest appeal
+	bans, pagination, err := s.banRepo.ListActiveBans(req.ForumID, &req.ReqPagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bans, pagination, nil
+}
+
+func (s *forumService) AppealBan(req *request.ReqAppealBan, user *lib.UserData) (*models.BanAppeal, error) {
+	var appeal *models.BanAppeal
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		banRepo := s.banRepo.WithTx(tx)
+
+		// Check if user is banned from the forum
+		ban, _ := banRepo.GetActiveBan(req.ForumID, user.UserID)
+		if ban == nil {
+			return fmt.Errorf(helper.UserNotBanned)
+		}
+
+		// One appeal per ban may wait for review at a time
+		pending, _ := banRepo.GetPendingAppeal(ban.ID)
+		if pending != nil {
+			return fmt.Errorf(helper.AppealAlreadyPending)
+		}
+
+		appeal = &models.BanAppeal{
+			BanID:   ban.ID,
+			UserID:  user.UserID,
+			Message: req.Message,
+			Status:  constants.BanAppealPending,
+		}
+
+		return banRepo.CreateAppeal(appeal)
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return forums, nil
+	return appeal, nil
+}
+
+func (s *forumService) ReviewBanAppeal(req *request.ReqReviewBanAppeal, user *lib.UserData) error {
+	var ban *models.ForumBan
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		banRepo := s.banRepo.WithTx(tx)
+
+		// Get the appeal by id
+		appeal, _ := banRepo.GetAppealByID(req.AppealID)
+		if appeal == nil {
+			return fmt.Errorf(helper.AppealNotFound)
+		}
+
+		var err error
+		ban, err = banRepo.GetBanByID(appeal.BanID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := authorizeModeration(s.repository.WithTx(tx), ban.ForumID, user, ActionManageBans); err != nil {
+			return err
+		}
+
+		if appeal.Status != constants.BanAppealPending {
+			return fmt.Errorf(helper.AppealAlreadyReviewed)
+		}
+
+		if err := banRepo.ReviewAppeal(appeal, req.Status, user.UserID); err != nil {
+			return err
+		}
+
+		// An accepted appeal lifts the ban, the user may then join again
+		if req.Status == constants.BanAppealAccepted {
+			return banRepo.LiftBan(ban, user.UserID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.BanAppealReviewed{Actor: *user, ForumID: ban.ForumID, UserID: ban.UserID, Status: req.Status})
+
+	return nil
+}
+
+// normalizeAttachmentTypes checks a comma separated list of content types
+// against the supported attachment types
+func normalizeAttachmentTypes(list string) (string, error) {
+	var types []string
+	for _, t := range strings.Split(list, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+
+		if _, ok := constants.AttachmentExtensions[t]; !ok {
+			return "", fmt.Errorf(helper.AttachmentTypeInvalid)
+		}
+
+		types = append(types, t)
+	}
+
+	if len(types) == 0 {
+		return "", fmt.Errorf(helper.AttachmentTypeInvalid)
+	}
+
+	return strings.Join(types, ","), nil
+}
+
+// reindexForum hands the threads and replies of a restored forum back to the
+// search index. Failures are only logged, like the other index writes.
+func reindexForum(repo repository.ForumRepository, searchIndex repository.SearchIndex, forumID uint) {
+	docs, err := repo.ListSearchDocuments(forumID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	for i := range docs {
+		if err := searchIndex.Index(&docs[i]); err != nil {
+			lib.CommonLogger().Error(err)
+		}
+	}
 }
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+)
+
+// ModerationAction is a forum action that only moderators may perform
+type ModerationAction string
+
+const (
+	ActionEditForum        ModerationAction = "edit_forum"
+	ActionChangeForumImage ModerationAction = "change_forum_image"
+	ActionDeleteForum      ModerationAction = "delete_forum"
+	ActionManageModerators ModerationAction = "manage_moderators"
+	ActionRemoveMember     ModerationAction = "remove_member"
+	ActionRemoveModerator  ModerationAction = "remove_moderator"
+	ActionManageBans       ModerationAction = "manage_bans"
+	ActionManageMembers    ModerationAction = "manage_members"
+	ActionDeleteThread     ModerationAction = "delete_thread"
+	ActionDeleteReply      ModerationAction = "delete_reply"
+)
+
+// moderationPermissions is the permission matrix of moderator ranks. Every
+// moderation check goes through it, so changing what a rank may do only
+// needs a change here.
+var moderationPermissions = map[string]map[ModerationAction]bool{
+	constants.ModeratorRankHead: {
+		ActionEditForum:        true,
+		ActionChangeForumImage: true,
+		ActionDeleteForum:      true,
+		ActionManageModerators: true,
+		ActionRemoveMember:     true,
+		ActionRemoveModerator:  true,
+		ActionManageBans:       true,
+		ActionManageMembers:    true,
+		ActionDeleteThread:     true,
+		ActionDeleteReply:      true,
+	},
+	constants.ModeratorRankMember: {
+		ActionChangeForumImage: true,
+		ActionRemoveMember:     true,
+		ActionManageBans:       true,
+		ActionManageMembers:    true,
+		ActionDeleteThread:     true,
+		ActionDeleteReply:      true,
+	},
+}
+
+// moderatorRanks orders the ranks from lowest to highest. Members without a
+// moderator row rank below all of them.
+var moderatorRanks = []string{
+	constants.ModeratorRankMember,
+	constants.ModeratorRankHead,
+}
+
+// rankLevel returns the position of rank in moderatorRanks, counting from 1
+func rankLevel(rank string) int {
+	for i, r := range moderatorRanks {
+		if r == rank {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// RankCan reports whether a moderator of the given rank may perform action
+func RankCan(rank string, action ModerationAction) bool {
+	return moderationPermissions[rank][action]
+}
+
+// authorizeModeration checks that the user moderates the forum with a rank
+// that allows the action, and returns their moderator row
+func authorizeModeration(repo repository.ForumRepository, forumID uint, user *lib.UserData, action ModerationAction) (*models.Moderator, error) {
+	moderator, _ := repo.GetModeratorByID(forumID, user.UserID)
+	return checkModerator(moderator, action)
+}
+
+// authorizeDeletedForumModeration is authorizeModeration for a deleted
+// forum, checked against the moderators deleted along with it
+func authorizeDeletedForumModeration(repo repository.ForumRepository, forum *models.Forum, user *lib.UserData, action ModerationAction) (*models.Moderator, error) {
+	moderator, _ := repo.GetDeletedForumModerator(forum, user.UserID)
+	return checkModerator(moderator, action)
+}
+
+// checkModerator checks that moderator exists and has a rank that allows the
+// action
+func checkModerator(moderator *models.Moderator, action ModerationAction) (*models.Moderator, error) {
+	if moderator == nil {
+		return nil, fmt.Errorf(helper.UserNotModerator)
+	}
+
+	if !RankCan(moderator.Rank, action) {
+		return nil, fmt.Errorf(helper.RankNotAuthorized)
+	}
+
+	return moderator, nil
+}
+
+// authorizeModerationOf is authorizeModeration for actions aimed at another
+// member of the forum, who has to rank below the user
+func authorizeModerationOf(repo repository.ForumRepository, forumID uint, user *lib.UserData, action ModerationAction, targetID uint) (*models.Moderator, error) {
+	moderator, err := authorizeModeration(repo, forumID, user, action)
+	if err != nil {
+		return nil, err
+	}
+
+	target, _ := repo.GetModeratorByID(forumID, targetID)
+	if target != nil && rankLevel(target.Rank) >= rankLevel(moderator.Rank) {
+		return nil, fmt.Errorf(helper.TargetRankNotLower)
+	}
+
+	return moderator, nil
+}
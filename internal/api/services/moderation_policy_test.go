@@ -0,0 +1,203 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"gorm.io/gorm"
+)
+
+// moderatorRepoStub answers moderator lookups from maps keyed by user id,
+// any other repository call panics
+type moderatorRepoStub struct {
+	repository.ForumRepository
+	moderators map[uint]*models.Moderator
+	// deleted holds the moderator rows deleted together with their forum
+	deleted map[uint]*models.Moderator
+}
+
+func (r *moderatorRepoStub) GetModeratorByID(forumID uint, userID uint) (*models.Moderator, error) {
+	moderator, ok := r.moderators[userID]
+	if !ok || moderator.ForumID != forumID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return moderator, nil
+}
+
+func (r *moderatorRepoStub) GetDeletedForumModerator(forum *models.Forum, userID uint) (*models.Moderator, error) {
+	moderator, ok := r.deleted[userID]
+	if !ok || moderator.ForumID != forum.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return moderator, nil
+}
+
+func TestRankCan(t *testing.T) {
+	tests := []struct {
+		action ModerationAction
+		head   bool
+		member bool
+	}{
+		{ActionEditForum, true, false},
+		{ActionChangeForumImage, true, true},
+		{ActionDeleteForum, true, false},
+		{ActionManageModerators, true, false},
+		{ActionRemoveMember, true, true},
+		{ActionRemoveModerator, true, false},
+		{ActionManageBans, true, true},
+		{ActionManageMembers, true, true},
+		{ActionDeleteThread, true, true},
+		{ActionDeleteReply, true, true},
+	}
+
+	// Every action in the matrix has to be covered by the table
+	covered := make(map[ModerationAction]bool, len(tests))
+	for _, tt := range tests {
+		covered[tt.action] = true
+	}
+	for _, permissions := range moderationPermissions {
+		for action := range permissions {
+			if !covered[action] {
+				t.Errorf("action %q is missing from the test table", action)
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			if got := RankCan(constants.ModeratorRankHead, tt.action); got != tt.head {
+				t.Errorf("RankCan(Head) = %v, want %v", got, tt.head)
+			}
+			if got := RankCan(constants.ModeratorRankMember, tt.action); got != tt.member {
+				t.Errorf("RankCan(Member) = %v, want %v", got, tt.member)
+			}
+			if RankCan("", tt.action) {
+				t.Error("RankCan allows a user without a rank")
+			}
+		})
+	}
+}
+
+func TestAuthorizeModeration(t *testing.T) {
+	const forumID = 1
+
+	repo := &moderatorRepoStub{moderators: map[uint]*models.Moderator{
+		// The creator of the forum is its first head
+		1: {UserID: 1, ForumID: forumID, Rank: constants.ModeratorRankHead},
+		2: {UserID: 2, ForumID: forumID, Rank: constants.ModeratorRankMember},
+		3: {UserID: 3, ForumID: forumID, Rank: constants.ModeratorRankMember},
+		// Moderates another forum only
+		4: {UserID: 4, ForumID: forumID + 1, Rank: constants.ModeratorRankHead},
+	}}
+
+	tests := []struct {
+		name    string
+		userID  uint
+		action  ModerationAction
+		wantErr string
+	}{
+		{"not a moderator", 5, ActionDeleteThread, helper.UserNotModerator},
+		{"moderator of another forum", 4, ActionDeleteThread, helper.UserNotModerator},
+		{"creator deletes the forum", 1, ActionDeleteForum, ""},
+		{"creator manages moderators", 1, ActionManageModerators, ""},
+		{"member deletes a thread", 2, ActionDeleteThread, ""},
+		{"member deletes the forum", 2, ActionDeleteForum, helper.RankNotAuthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &lib.UserData{UserID: tt.userID}
+			moderator, err := authorizeModeration(repo, forumID, user, tt.action)
+			assertAuthorized(t, moderator, err, tt.userID, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthorizeModerationOf(t *testing.T) {
+	const forumID = 1
+
+	repo := &moderatorRepoStub{moderators: map[uint]*models.Moderator{
+		1: {UserID: 1, ForumID: forumID, Rank: constants.ModeratorRankHead},
+		2: {UserID: 2, ForumID: forumID, Rank: constants.ModeratorRankMember},
+		3: {UserID: 3, ForumID: forumID, Rank: constants.ModeratorRankMember},
+	}}
+
+	tests := []struct {
+		name     string
+		userID   uint
+		targetID uint
+		action   ModerationAction
+		wantErr  string
+	}{
+		{"not a moderator", 5, 6, ActionRemoveMember, helper.UserNotModerator},
+		{"head acts on a member moderator", 1, 2, ActionRemoveModerator, ""},
+		{"head acts on themselves", 1, 1, ActionRemoveModerator, helper.TargetRankNotLower},
+		{"member acts on a plain member", 2, 6, ActionRemoveMember, ""},
+		{"member acts on an equal rank", 2, 3, ActionRemoveMember, helper.TargetRankNotLower},
+		{"member acts on a higher rank", 2, 1, ActionRemoveMember, helper.TargetRankNotLower},
+		{"member lacks the permission", 2, 6, ActionRemoveModerator, helper.RankNotAuthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &lib.UserData{UserID: tt.userID}
+			moderator, err := authorizeModerationOf(repo, forumID, user, tt.action, tt.targetID)
+			assertAuthorized(t, moderator, err, tt.userID, tt.wantErr)
+		})
+	}
+}
+
+func assertAuthorized(t *testing.T, moderator *models.Moderator, err error, userID uint, wantErr string) {
+	t.Helper()
+
+	if wantErr != "" {
+		if err == nil || err.Error() != wantErr {
+			t.Fatalf("err = %v, want %q", err, wantErr)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if moderator == nil || moderator.UserID != userID {
+		t.Fatalf("moderator = %+v, want the row of user %d", moderator, userID)
+	}
+}
+
+func TestAuthorizeDeletedForumModeration(t *testing.T) {
+	forum := &models.Forum{ID: 1}
+
+	repo := &moderatorRepoStub{
+		// Nobody moderates the forum while it is deleted
+		moderators: map[uint]*models.Moderator{},
+		deleted: map[uint]*models.Moderator{
+			1: {UserID: 1, ForumID: forum.ID, Rank: constants.ModeratorRankHead},
+			2: {UserID: 2, ForumID: forum.ID, Rank: constants.ModeratorRankMember},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		userID  uint
+		wantErr string
+	}{
+		{"head before the deletion", 1, ""},
+		{"member moderator before the deletion", 2, helper.RankNotAuthorized},
+		{"not a moderator", 3, helper.UserNotModerator},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &lib.UserData{UserID: tt.userID}
+			moderator, err := authorizeDeletedForumModeration(repo, forum, user, ActionDeleteForum)
+			assertAuthorized(t, moderator, err, tt.userID, tt.wantErr)
+		})
+	}
+}
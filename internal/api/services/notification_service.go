@@ -0,0 +1,53 @@
+package services
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+)
+
+type NotificationService interface {
+	ListNotifications(req *request.ReqListNotification, user *lib.UserData) (*response.ResListNotification, *response.ResPagination, error)
+	MarkRead(req *request.ReqMarkNotificationRead, user *lib.UserData) error
+	MarkAllRead(user *lib.UserData) error
+}
+
+type notificationService struct {
+	repository repository.NotificationRepository
+}
+
+func NewNotificationService(repository repository.NotificationRepository) NotificationService {
+	return &notificationService{repository}
+}
+
+func (s *notificationService) ListNotifications(req *request.ReqListNotification, user *lib.UserData) (*response.ResListNotification, *response.ResPagination, error) {
+	// Get a page of the user's notifications, newest first
+	notifications, pagination, err := s.repository.ListNotifications(user.UserID, req.Unread, &req.ReqPagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unreadCount, err := s.repository.CountUnread(user.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+
+	return &response.ResListNotification{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+	}, pagination, nil
+}
+
+func (s *notificationService) MarkRead(req *request.ReqMarkNotificationRead, user *lib.UserData) error {
+	return s.repository.MarkRead(user.UserID, req.NotificationIDs)
+}
+
+func (s *notificationService) MarkAllRead(user *lib.UserData) error {
+	return s.repository.MarkAllRead(user.UserID)
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/events"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+)
+
+// notificationSubscriber turns the events published by the services into
+// notifications for the users they concern
+type notificationSubscriber struct {
+	bus        events.Bus
+	repository repository.NotificationRepository
+	forumRepo  repository.ForumRepository
+	threadRepo repository.ThreadRepository
+}
+
+// SubscribeNotifications registers the notification handlers on the bus. A
+// new kind of notification only needs a new handler here.
+func SubscribeNotifications(
+	bus events.Bus,
+	repository repository.NotificationRepository,
+	forumRepo repository.ForumRepository,
+	threadRepo repository.ThreadRepository,
+) {
+	s := &notificationSubscriber{bus, repository, forumRepo, threadRepo}
+
+	bus.Subscribe(events.ReplyCreatedEvent, s.onReplyCreated)
+	bus.Subscribe(events.ThreadVotedEvent, s.onThreadVoted)
+	bus.Subscribe(events.ReplyVotedEvent, s.onReplyVoted)
+	bus.Subscribe(events.MemberRemovedEvent, s.onMemberRemoved)
+	bus.Subscribe(events.ModeratorPromotedEvent, s.onModeratorPromoted)
+	bus.Subscribe(events.ModeratorDemotedEvent, s.onModeratorDemoted)
+	bus.Subscribe(events.HeadModeratorTransferredEvent, s.onHeadModeratorTransferred)
+	bus.Subscribe(events.UsersMentionedEvent, s.onUsersMentioned)
+	bus.Subscribe(events.BanAppealReviewedEvent, s.onBanAppealReviewed)
+	bus.Subscribe(events.JoinRequestReviewedEvent, s.onJoinRequestReviewed)
+}
+
+func (s *notificationSubscriber) onReplyCreated(event events.Event) {
+	e := event.(events.ReplyCreated)
+
+	s.notify(&e.Actor, &models.Notification{
+		UserID:   e.Thread.CreatedBy,
+		Type:     constants.NotificationThreadReply,
+		Message:  fmt.Sprintf("%s replied to your thread \"%s\"", e.Actor.Name, e.Thread.Title),
+		ForumID:  &e.Thread.ForumID,
+		ThreadID: &e.Thread.ID,
+		ReplyID:  &e.Reply.ID,
+	})
+
+	if e.Reply.ParentReplyID == nil {
+		return
+	}
+
+	parent, err := s.threadRepo.GetReplyByID(*e.Reply.ParentReplyID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	// the thread author already heard about it
+	if parent.CreatedBy == e.Thread.CreatedBy {
+		return
+	}
+
+	s.notify(&e.Actor, &models.Notification{
+		UserID:   parent.CreatedBy,
+		Type:     constants.NotificationReplyReply,
+		Message:  fmt.Sprintf("%s replied to your reply in \"%s\"", e.Actor.Name, e.Thread.Title),
+		ForumID:  &e.Thread.ForumID,
+		ThreadID: &e.Thread.ID,
+		ReplyID:  &e.Reply.ID,
+	})
+}
+
+func (s *notificationSubscriber) onThreadVoted(event events.Event) {
+	e := event.(events.ThreadVoted)
+
+	s.notify(&e.Actor, &models.Notification{
+		UserID:   e.Thread.CreatedBy,
+		Type:     constants.NotificationThreadVote,
+		Message:  fmt.Sprintf("%s %s your thread \"%s\"", e.Actor.Name, voteVerb(e.Vote), e.Thread.Title),
+		ForumID:  &e.Thread.ForumID,
+		ThreadID: &e.Thread.ID,
+	})
+}
+
+func (s *notificationSubscriber) onReplyVoted(event events.Event) {
+	e := event.(events.ReplyVoted)
+
+	s.notify(&e.Actor, &models.Notification{
+		UserID:   e.Reply.CreatedBy,
+		Type:     constants.NotificationReplyVote,
+		Message:  fmt.Sprintf("%s %s your reply in \"%s\"", e.Actor.Name, voteVerb(e.Vote), e.Thread.Title),
+		ForumID:  &e.Thread.ForumID,
+		ThreadID: &e.Thread.ID,
+		ReplyID:  &e.Reply.ID,
+	})
+}
+
+func (s *notificationSubscriber) onMemberRemoved(event events.Event) {
+	e := event.(events.MemberRemoved)
+
+	s.notifyForum(&e.Actor, e.ForumID, e.UserID, constants.NotificationRemovedFromForum, "You were removed from %s")
+}
+
+func (s *notificationSubscriber) onModeratorPromoted(event events.Event) {
+	e := event.(events.ModeratorPromoted)
+
+	s.notifyForum(&e.Actor, e.ForumID, e.UserID, constants.NotificationModeratorPromoted, "You are now a moderator of %s")
+}
+
+func (s *notificationSubscriber) onModeratorDemoted(event events.Event) {
+	e := event.(events.ModeratorDemoted)
+
+	s.notifyForum(&e.Actor, e.ForumID, e.UserID, constants.NotificationModeratorDemoted, "You are no longer a moderator of %s")
+}
+
+func (s *notificationSubscriber) onHeadModeratorTransferred(event events.Event) {
+	e := event.(events.HeadModeratorTransferred)
+
+	s.notifyForum(&e.Actor, e.ForumID, e.UserID, constants.NotificationHeadModerator, "You are now the head moderator of %s")
+}
+
+func (s *notificationSubscriber) onUsersMentioned(event events.Event) {
+	e := event.(events.UsersMentioned)
+
+	message := fmt.Sprintf("%s mentioned you in \"%s\"", e.Actor.Name, e.Thread.Title)
+	var replyID *uint
+	if e.Reply != nil {
+		message = fmt.Sprintf("%s mentioned you in a reply in \"%s\"", e.Actor.Name, e.Thread.Title)
+		replyID = &e.Reply.ID
+	}
+
+	for _, userID := range e.UserIDs {
+		s.notify(&e.Actor, &models.Notification{
+			UserID:   userID,
+			Type:     constants.NotificationMention,
+			Message:  message,
+			ForumID:  &e.Thread.ForumID,
+			ThreadID: &e.Thread.ID,
+			ReplyID:  replyID,
+		})
+	}
+}
+
+func (s *notificationSubscriber) onBanAppealReviewed(event events.Event) {
+	e := event.(events.BanAppealReviewed)
+
+	s.notifyForum(&e.Actor, e.ForumID, e.UserID, constants.NotificationBanAppealReviewed, "Your ban appeal for %s was "+e.Status)
+}
+
+func (s *notificationSubscriber) onJoinRequestReviewed(event events.Event) {
+	e := event.(events.JoinRequestReviewed)
+
+	s.notifyForum(&e.Actor, e.ForumID, e.UserID, constants.NotificationJoinRequest, "Your request to join %s was "+e.Status)
+}
+
+// notifyForum sends a notification about a forum, format taking the forum
+// name
+func (s *notificationSubscriber) notifyForum(actor *lib.UserData, forumID uint, userID uint, notificationType string, format string) {
+	forum, err := s.forumRepo.GetForumById(forumID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	s.notify(actor, &models.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Message: fmt.Sprintf(format, forum.ForumName),
+		ForumID: &forum.ID,
+	})
+}
+
+// notify saves the notification, unless the user would be notified about
+// their own action
+func (s *notificationSubscriber) notify(actor *lib.UserData, notification *models.Notification) {
+	if notification.UserID == actor.UserID {
+		return
+	}
+
+	notification.ActorID = &actor.UserID
+
+	if err := s.repository.CreateNotification(notification); err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	s.bus.Publish(events.NotificationCreated{Notification: *notification})
+}
+
+func voteVerb(vote bool) string {
+	if vote {
+		return "upvoted"
+	}
+
+	return "downvoted"
+}
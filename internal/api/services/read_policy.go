@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+)
+
+// The read policy decides whose threads, replies and vote totals a user may
+// see. The content of a forum is readable while the forum is not deleted,
+// by anyone when the forum is public and only by its members otherwise.
+// Lists apply the same rules in SQL, see ForumRepository.ListVisibleForumIDs.
+
+// authorizeForumRead checks that the user may read the content of the forum.
+// The forum is returned along with the error when it exists but the user may
+// not read it.
+func authorizeForumRead(repo repository.ForumRepository, forumID uint, user *lib.UserData) (*models.Forum, error) {
+	// Deleted forums are not found
+	forum, err := repo.GetForumById(forumID)
+	if err != nil {
+		return nil, fmt.Errorf(helper.ForumNotFound)
+	}
+
+	if forum.Visibility == constants.ForumVisibilityPublic {
+		return forum, nil
+	}
+
+	userForum, _ := repo.GetUserForumByID(forum.ID, user.UserID)
+	if userForum == nil {
+		return forum, fmt.Errorf(helper.ForumMembersOnly)
+	}
+
+	return forum, nil
+}
+
+// authorizeThreadRead checks that the thread is not deleted and that the
+// user may read the content of its forum
+func authorizeThreadRead(threadRepo repository.ThreadRepository, forumRepo repository.ForumRepository, threadID uint, user *lib.UserData) (*models.Thread, error) {
+	thread, err := threadRepo.GetThreadByID(threadID)
+	if err != nil {
+		return nil, fmt.Errorf(helper.ThreadNotFound)
+	}
+
+	if _, err := authorizeForumRead(forumRepo, thread.ForumID, user); err != nil {
+		return nil, err
+	}
+
+	return thread, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/events"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/realtime"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+)
+
+// realtimeSubscriber pushes the events published by the services to the
+// streaming clients following them
+type realtimeSubscriber struct {
+	hub        *realtime.Hub
+	threadRepo repository.ThreadRepository
+}
+
+func SubscribeRealtime(bus events.Bus, hub *realtime.Hub, threadRepo repository.ThreadRepository) {
+	s := &realtimeSubscriber{hub, threadRepo}
+
+	bus.Subscribe(events.ThreadCreatedEvent, s.onThreadCreated)
+	bus.Subscribe(events.ReplyCreatedEvent, s.onReplyCreated)
+	bus.Subscribe(events.ThreadVotedEvent, s.onThreadVotesChanged)
+	bus.Subscribe(events.ThreadVoteRetractedEvent, s.onThreadVotesChanged)
+	bus.Subscribe(events.ReplyVotedEvent, s.onReplyVotesChanged)
+	bus.Subscribe(events.ReplyVoteRetractedEvent, s.onReplyVotesChanged)
+	bus.Subscribe(events.NotificationCreatedEvent, s.onNotificationCreated)
+}
+
+func (s *realtimeSubscriber) onThreadCreated(event events.Event) {
+	e := event.(events.ThreadCreated)
+
+	s.publish(realtime.ForumTopic(e.Thread.ForumID), constants.StreamThreadCreated, response.ResStreamThread{
+		ForumID:   e.Thread.ForumID,
+		ThreadID:  e.Thread.ID,
+		Title:     e.Thread.Title,
+		CreatedBy: e.Actor.Name,
+		CreatedAt: e.Thread.CreatedAt,
+	})
+}
+
+func (s *realtimeSubscriber) onReplyCreated(event events.Event) {
+	e := event.(events.ReplyCreated)
+
+	s.publish(realtime.ThreadTopic(e.Thread.ID), constants.StreamReplyCreated, response.ResStreamReply{
+		ThreadID:      e.Thread.ID,
+		ReplyID:       e.Reply.ID,
+		ParentReplyID: e.Reply.ParentReplyID,
+		CreatedBy:     e.Actor.Name,
+		CreatedAt:     e.Reply.CreatedAt,
+	})
+}
+
+// onThreadVotesChanged reloads the thread since the event carries the
+// thread as it was before the vote
+func (s *realtimeSubscriber) onThreadVotesChanged(event events.Event) {
+	var threadID uint
+	switch e := event.(type) {
+	case events.ThreadVoted:
+		threadID = e.Thread.ID
+	case events.ThreadVoteRetracted:
+		threadID = e.Thread.ID
+	}
+
+	thread, err := s.threadRepo.GetThreadByID(threadID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	s.publish(realtime.ThreadTopic(thread.ID), constants.StreamVotesChanged, response.ResStreamVotes{
+		ThreadID:       thread.ID,
+		TotalUpvotes:   thread.NumberOfUpvotes,
+		TotalDownvotes: thread.NumberOfDownvotes,
+	})
+}
+
+func (s *realtimeSubscriber) onReplyVotesChanged(event events.Event) {
+	var replyID uint
+	switch e := event.(type) {
+	case events.ReplyVoted:
+		replyID = e.Reply.ID
+	case events.ReplyVoteRetracted:
+		replyID = e.Reply.ID
+	}
+
+	reply, err := s.threadRepo.GetReplyByID(replyID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	s.publish(realtime.ThreadTopic(reply.ThreadID), constants.StreamVotesChanged, response.ResStreamVotes{
+		ThreadID:       reply.ThreadID,
+		ReplyID:        &reply.ID,
+		TotalUpvotes:   reply.NumberOfUpvotes,
+		TotalDownvotes: reply.NumberOfDownvotes,
+	})
+}
+
+func (s *realtimeSubscriber) onNotificationCreated(event events.Event) {
+	e := event.(events.NotificationCreated)
+
+	s.publish(realtime.UserTopic(e.Notification.UserID), constants.StreamNotificationCreated, e.Notification)
+}
+
+func (s *realtimeSubscriber) publish(topic string, name string, data interface{}) {
+	err := s.hub.Publish(&realtime.Message{Topic: topic, Event: name, Data: data})
+	if err != nil {
+		lib.CommonLogger().Error(err)
+	}
+}
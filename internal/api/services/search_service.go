@@ -0,0 +1,43 @@
+package services
+
+import (
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+)
+
+type SearchService interface {
+	Search(req *request.ReqSearch, user *lib.UserData) ([]response.ResSearchHit, *response.ResPagination, error)
+}
+
+type searchService struct {
+	searchIndex repository.SearchIndex
+	forumRepo   repository.ForumRepository
+}
+
+func NewSearchService(searchIndex repository.SearchIndex, forumRepo repository.ForumRepository) SearchService {
+	return &searchService{searchIndex, forumRepo}
+}
+
+func (s *searchService) Search(req *request.ReqSearch, user *lib.UserData) ([]response.ResSearchHit, *response.ResPagination, error) {
+	// Only search the forums the user can see
+	forumIDs, err := s.forumRepo.ListVisibleForumIDs(user.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Narrow the search down to a single forum when asked to
+	if req.ForumID != 0 {
+		var narrowed []uint
+		for _, id := range forumIDs {
+			if id == req.ForumID {
+				narrowed = append(narrowed, id)
+			}
+		}
+
+		forumIDs = narrowed
+	}
+
+	return s.searchIndex.Search(req, forumIDs)
+}
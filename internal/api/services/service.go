@@ -7,5 +7,11 @@ var Module = fx.Module("services",
 		NewUserService,
 		NewForumService,
 		NewThreadService,
+		NewAdminService,
+		NewSearchService,
+		NewNotificationService,
+		NewStreamService,
+		NewUploadService,
 	),
+	fx.Invoke(SubscribeNotifications, SubscribeRealtime),
 )
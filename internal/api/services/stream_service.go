@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/realtime"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+)
+
+type StreamService interface {
+	Subscribe(req *request.ReqStream, user *lib.UserData) (*realtime.Subscription, error)
+	Unsubscribe(subscription *realtime.Subscription)
+	// CheckStream tells whether the user may keep streaming, which stops
+	// once their session is revoked or they can no longer read one of the
+	// forums or threads they follow
+	CheckStream(req *request.ReqStream, user *lib.UserData) error
+}
+
+type streamService struct {
+	hub         *realtime.Hub
+	forumRepo   repository.ForumRepository
+	threadRepo  repository.ThreadRepository
+	userService UserService
+}
+
+func NewStreamService(hub *realtime.Hub, forumRepo repository.ForumRepository, threadRepo repository.ThreadRepository, userService UserService) StreamService {
+	return &streamService{hub, forumRepo, threadRepo, userService}
+}
+
+func (s *streamService) Subscribe(req *request.ReqStream, user *lib.UserData) (*realtime.Subscription, error) {
+	topics, err := s.topics(req, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.hub.Subscribe(topics...), nil
+}
+
+func (s *streamService) Unsubscribe(subscription *realtime.Subscription) {
+	s.hub.Unsubscribe(subscription)
+}
+
+func (s *streamService) CheckStream(req *request.ReqStream, user *lib.UserData) error {
+	if err := s.userService.CheckSession(user); err != nil {
+		return err
+	}
+
+	// Members who left or were banned from a forum stop receiving its events
+	_, err := s.topics(req, user)
+	return err
+}
+
+// topics returns the topics of the stream, checking that the user can read
+// every forum and thread it follows
+func (s *streamService) topics(req *request.ReqStream, user *lib.UserData) ([]string, error) {
+	// Only the forums the user can see may be followed
+	visibleIDs, err := s.forumRepo.ListVisibleForumIDs(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[uint]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+
+	// The user always receives their own notifications
+	topics := []string{realtime.UserTopic(user.UserID)}
+
+	for _, forumID := range req.ForumIDs {
+		if !visible[forumID] {
+			return nil, fmt.Errorf(helper.ForumNotFound)
+		}
+
+		topics = append(topics, realtime.ForumTopic(forumID))
+	}
+
+	for _, threadID := range req.ThreadIDs {
+		thread, err := s.threadRepo.GetThreadByID(threadID)
+		if err != nil || !visible[thread.ForumID] {
+			return nil, fmt.Errorf(helper.ThreadNotFound)
+		}
+
+		topics = append(topics, realtime.ThreadTopic(threadID))
+	}
+
+	return topics, nil
+}
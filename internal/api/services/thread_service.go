@@ -1,283 +1,532 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/drdofx/talk-parmad/internal/api/events"
 	"github.com/drdofx/talk-parmad/internal/api/helper"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
 	"github.com/drdofx/talk-parmad/internal/api/models"
 	"github.com/drdofx/talk-parmad/internal/api/repository"
 	"github.com/drdofx/talk-parmad/internal/api/request"
 	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
 )
 
 type ThreadService interface {
 	CreateThread(req *request.ReqSaveThread, user *lib.UserData) (*models.Thread, error)
 	VoteThread(req *request.ReqVoteThread, user *lib.UserData) (*models.ThreadVote, error)
+	RetractVoteThread(req *request.ReqRetractVoteThread, user *lib.UserData) error
 	EditThread(req *request.ReqEditThread, user *lib.UserData) (*models.Thread, error)
-	DetailThread(req *request.ReqDetailThread) (*response.ResDetailThread, error)
+	DetailThread(req *request.ReqDetailThread, user *lib.UserData) (*response.ResDetailThread, *response.ResPagination, error)
+	ListReplyChildren(req *request.ReqListReplyChildren, user *lib.UserData) ([]response.ResReplyField, *response.ResPagination, error)
 	CreateReply(req *request.ReqSaveReply, user *lib.UserData) (*models.Reply, error)
 	VoteReply(req *request.ReqVoteReply, user *lib.UserData) (*models.ReplyVote, error)
+	RetractVoteReply(req *request.ReqRetractVoteReply, user *lib.UserData) error
 	EditReply(req *request.ReqEditReply, user *lib.UserData) (*models.Reply, error)
-	ListUserThread(user *lib.UserData) ([]*response.ResListThread, error)
-	ListUserReply(user *lib.UserData) ([]*response.ResListThreadReply, error)
+	ListUserThread(user *lib.UserData, req *request.ReqPagination) ([]*response.ResListThread, *response.ResPagination, error)
+	ListUserReply(user *lib.UserData, req *request.ReqPagination) ([]*response.ResListThreadReply, *response.ResPagination, error)
+	ListMentionedThreads(user *lib.UserData, req *request.ReqPagination) ([]response.ResMentionedThread, *response.ResPagination, error)
 	GetThreadByID(threadID uint) (*models.Thread, error)
-	CheckModeratorForumFromThread(thread *models.Thread, user *lib.UserData) (bool, error)
-	DeleteThread(thread *models.Thread) error
-	GetThreadAndReplyByReplyID(replyID uint) (*models.Thread, *models.Reply, error)
-	DeleteReply(reply *models.Reply) error
+	DeleteThread(req *request.ReqDeleteThread, user *lib.UserData) error
+	RestoreThread(req *request.ReqRestoreThread, user *lib.UserData) error
+	DeleteReply(req *request.ReqDeleteReply, user *lib.UserData) error
+	RecountVotes() error
 }
 
+// defaultReplyTreeDepth is how many levels of replies DetailThread loads
+// when the client does not ask for a depth
+const defaultReplyTreeDepth = 3
+
+// maxMentionsPerPost caps how many @handles of a post are looked up, so a
+// post can't notify the whole forum at once
+const maxMentionsPerPost = 20
+
 type threadService struct {
 	repository      repository.ThreadRepository
 	forumRepo       repository.ForumRepository
+	attachmentRepo  repository.AttachmentRepository
+	mentionRepo     repository.MentionRepository
 	transactionRepo repository.TransactionRepository
+	searchIndex     repository.SearchIndex
+	bus             events.Bus
 }
 
 func NewThreadService(
 	repository repository.ThreadRepository,
 	forumRepo repository.ForumRepository,
+	attachmentRepo repository.AttachmentRepository,
+	mentionRepo repository.MentionRepository,
 	transactionRepo repository.TransactionRepository,
+	searchIndex repository.SearchIndex,
+	bus events.Bus,
 ) ThreadService {
-	return &threadService{repository, forumRepo, transactionRepo}
+	return &threadService{repository, forumRepo, attachmentRepo, mentionRepo, transactionRepo, searchIndex, bus}
 }
 
 func (s *threadService) CreateThread(req *request.ReqSaveThread, user *lib.UserData) (*models.Thread, error) {
-	tx := s.transactionRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+	var createdThread *models.Thread
+	var mentioned []uint
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
+
+		// Get the forum by id
+		forumIdInt, _ := strconv.Atoi(req.ForumID)
+		forum, err := forumRepo.GetForumById(uint(forumIdInt))
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get the forum by id
-	forumIdInt, _ := strconv.Atoi(req.ForumID)
-	forum, err := s.forumRepo.GetForumById(uint(forumIdInt))
-	if err != nil {
-		return nil, err
-	}
+		// Check if user a member of the requested forum
+		userForum, _ := forumRepo.GetUserForumByID(forum.ID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
 
-	// Check if user a member of the requested forum
-	userForum, _ := s.forumRepo.GetUserForumByID(forum.ID, user.UserID)
-	if userForum == nil {
-		return nil, fmt.Errorf(helper.UserNotMember)
-	}
+		// Create the thread for the forum
+		createdThread, err = repo.CreateThread(req, forum.ID, user.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Attach the files uploaded for the thread
+		err = s.attachmentRepo.WithTx(tx).LinkAttachments(req.AttachmentIDs, forum.ID, user.UserID, &createdThread.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		mentioned, err = s.saveMentions(tx, forum.ID, createdThread.ID, nil, createdThread.Text, user)
+		return err
+	})
 
-	// Create the thread for the forum
-	createdThread, err := s.repository.CreateThread(req, forum.ID, user.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.transactionRepo.CommitTransaction(tx); err != nil {
-		return nil, err
-	}
+	s.indexThread(createdThread)
+	s.bus.Publish(events.ThreadCreated{Actor: *user, Thread: *createdThread})
+	s.publishMentions(user, createdThread, nil, mentioned)
 
 	return createdThread, nil
 }
 
 func (s *threadService) VoteThread(req *request.ReqVoteThread, user *lib.UserData) (*models.ThreadVote, error) {
-	tx := s.transactionRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+	var threadVote *models.ThreadVote
+	var votedThread *models.Thread
+	var changed bool
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
+
+		// Get thread by id
+		threadIdInt, _ := strconv.Atoi(req.ThreadID)
+		thread, err := repo.GetThreadByID(uint(threadIdInt))
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get thread by id
-	threadIdInt, _ := strconv.Atoi(req.ThreadID)
-	thread, err := s.repository.GetThreadByID(uint(threadIdInt))
-	if err != nil {
-		return nil, err
-	}
+		// Check if user a member of the requested forum
+		userForum, _ := forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
 
-	// Check if user a member of the requested forum
-	userForum, _ := s.forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
-	if userForum == nil {
-		return nil, fmt.Errorf(helper.UserNotMember)
-	}
+		// Update the thread data vote
+		votedThread = thread
+		threadVote, changed, err = repo.CreateOrUpdateThreadVote(thread, req, user.UserID)
+		return err
+	})
 
-	// Update the thread data vote
-	threadVote, err := s.repository.CreateOrUpdateThreadVote(thread, req, user.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.transactionRepo.CommitTransaction(tx); err != nil {
-		return nil, err
+	// Voting the same way again changes nothing and notifies nobody
+	if changed {
+		s.bus.Publish(events.ThreadVoted{Actor: *user, Thread: *votedThread, Vote: req.Vote})
 	}
 
 	return threadVote, nil
 }
 
-func (s *threadService) EditThread(req *request.ReqEditThread, user *lib.UserData) (*models.Thread, error) {
-	tx := s.transactionRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+func (s *threadService) RetractVoteThread(req *request.ReqRetractVoteThread, user *lib.UserData) error {
+	var votedThread *models.Thread
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
+
+		// Get thread by id
+		threadIdInt, _ := strconv.Atoi(req.ThreadID)
+		thread, err := repo.GetThreadByID(uint(threadIdInt))
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get thread by id
-	threadIdInt, _ := strconv.Atoi(req.ThreadID)
-	thread, err := s.repository.GetThreadByID(uint(threadIdInt))
+		// Check if user a member of the requested forum
+		userForum, _ := forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// Remove the user's vote from the thread
+		votedThread = thread
+		err = repo.DeleteThreadVote(thread, user.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf(helper.VoteNotFound)
+		}
+
+		return err
+	})
+
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Check if user created the thread
-	if thread.CreatedBy != user.UserID {
-		return nil, fmt.Errorf(helper.UserNotCreatedThread)
-	}
+	s.bus.Publish(events.ThreadVoteRetracted{Actor: *user, Thread: *votedThread})
+
+	return nil
+}
+
+func (s *threadService) EditThread(req *request.ReqEditThread, user *lib.UserData) (*models.Thread, error) {
+	var updatedThread *models.Thread
+	var mentioned []uint
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get thread by id
+		threadIdInt, _ := strconv.Atoi(req.ThreadID)
+		thread, err := repo.GetThreadByID(uint(threadIdInt))
+		if err != nil {
+			return err
+		}
+
+		// Check if user created the thread
+		if thread.CreatedBy != user.UserID {
+			return fmt.Errorf(helper.UserNotCreatedThread)
+		}
+
+		// Update the thread data
+		updatedThread, err = repo.UpdateThread(thread, req)
+		if err != nil {
+			return err
+		}
+
+		mentioned, err = s.saveMentions(tx, updatedThread.ForumID, updatedThread.ID, nil, updatedThread.Text, user)
+		return err
+	})
 
-	// Update the thread data
-	updatedThread, err := s.repository.UpdateThread(thread, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.transactionRepo.CommitTransaction(tx); err != nil {
-		return nil, err
-	}
+	s.indexThread(updatedThread)
+	s.publishMentions(user, updatedThread, nil, mentioned)
 
 	return updatedThread, nil
 }
 
-func (s *threadService) ListUserThread(user *lib.UserData) ([]*response.ResListThread, error) {
+func (s *threadService) ListUserThread(user *lib.UserData, req *request.ReqPagination) ([]*response.ResListThread, *response.ResPagination, error) {
 	// Get the thread data
-	threads, err := s.repository.ListUserThread(user)
+	threads, pagination, err := s.repository.ListUserThread(user, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return threads, nil
+	for _, thread := range threads {
+		thread.TextHTML = lib.RenderMarkdown(thread.Text)
+	}
+
+	return threads, pagination, nil
 }
 
-func (s *threadService) ListUserReply(user *lib.UserData) ([]*response.ResListThreadReply, error) {
+func (s *threadService) ListUserReply(user *lib.UserData, req *request.ReqPagination) ([]*response.ResListThreadReply, *response.ResPagination, error) {
 	// Get the reply data
-	replies, err := s.repository.ListUserReply(user)
+	replies, pagination, err := s.repository.ListUserReply(user, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return replies, nil
+	return replies, pagination, nil
 }
 
-func (s *threadService) DetailThread(req *request.ReqDetailThread) (*response.ResDetailThread, error) {
-	// Get the thread data, including its reply
-	threadIdInt, _ := strconv.Atoi(req.ThreadID)
-	thread, err := s.repository.DetailThread(uint(threadIdInt))
+func (s *threadService) ListMentionedThreads(user *lib.UserData, req *request.ReqPagination) ([]response.ResMentionedThread, *response.ResPagination, error) {
+	// Get the threads the user was mentioned in
+	threads, pagination, err := s.mentionRepo.ListMentionedThreads(user.UserID, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return thread, nil
-}
+	for i := range threads {
+		threads[i].TextHTML = lib.RenderMarkdown(threads[i].Text)
+	}
 
-func (s *threadService) CreateReply(req *request.ReqSaveReply, user *lib.UserData) (*models.Reply, error) {
-	tx := s.transactionRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
-		}
-	}()
+	return threads, pagination, nil
+}
 
-	// Get the thread by id
+func (s *threadService) DetailThread(req *request.ReqDetailThread, user *lib.UserData) (*response.ResDetailThread, *response.ResPagination, error) {
 	threadIdInt, _ := strconv.Atoi(req.ThreadID)
-	thread, err := s.repository.GetThreadByID(uint(threadIdInt))
+	if _, err := authorizeThreadRead(s.repository, s.forumRepo, uint(threadIdInt), user); err != nil {
+		return nil, nil, err
+	}
+
+	// Get the thread data, including a page of its replies and the user's votes
+	thread, pagination, err := s.repository.DetailThread(uint(threadIdInt), user.UserID, replyTreeDepth(req.Depth), &req.ReqPagination)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Check if user a member of the requested forum
-	userForum, _ := s.forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
-	if userForum == nil {
-		return nil, fmt.Errorf(helper.UserNotMember)
+	thread.ThreadData.TextHTML = lib.RenderMarkdown(thread.ThreadData.Text)
+	renderReplies(thread.ReplyData)
+
+	return thread, pagination, nil
+}
+
+func (s *threadService) ListReplyChildren(req *request.ReqListReplyChildren, user *lib.UserData) ([]response.ResReplyField, *response.ResPagination, error) {
+	// Get the reply by id
+	replyIdInt, _ := strconv.Atoi(req.ReplyID)
+	reply, err := s.repository.GetReplyByID(uint(replyIdInt))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := authorizeThreadRead(s.repository, s.forumRepo, reply.ThreadID, user); err != nil {
+		return nil, nil, err
 	}
 
-	// Create the reply for the thread
-	createdReply, err := s.repository.CreateReply(req, thread.ID, user.UserID)
+	// Get the next page of the reply's children, including their own children
+	replies, pagination, err := s.repository.ListReplyChildren(reply.ID, user.UserID, replyTreeDepth(req.Depth), &req.ReqPagination)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if err := s.transactionRepo.CommitTransaction(tx); err != nil {
-		return nil, err
+	renderReplies(replies)
+
+	return replies, pagination, nil
+}
+
+// replyTreeDepth returns how many levels of replies to load, defaulting to
+// defaultReplyTreeDepth when the client did not ask for a depth
+func replyTreeDepth(depth int) int {
+	if depth <= 0 {
+		return defaultReplyTreeDepth
 	}
 
-	return createdReply, nil
+	return depth
 }
 
-func (s *threadService) VoteReply(req *request.ReqVoteReply, user *lib.UserData) (*models.ReplyVote, error) {
-	tx := s.transactionRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+// renderReplies renders the Markdown text of a tree of replies to HTML
+func renderReplies(replies []response.ResReplyField) {
+	for i := range replies {
+		replies[i].TextHTML = lib.RenderMarkdown(replies[i].Text)
+		renderReplies(replies[i].Children)
+	}
+}
+
+func (s *threadService) CreateReply(req *request.ReqSaveReply, user *lib.UserData) (*models.Reply, error) {
+	var createdReply *models.Reply
+	var repliedThread *models.Thread
+	var mentioned []uint
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
+
+		// Get the thread by id
+		threadIdInt, _ := strconv.Atoi(req.ThreadID)
+		thread, err := repo.GetThreadByID(uint(threadIdInt))
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get reply by id
-	replyIdInt, _ := strconv.Atoi(req.ReplyID)
-	reply, err := s.repository.GetReplyByID(uint(replyIdInt))
-	if err != nil {
-		return nil, err
-	}
+		// Check if user a member of the requested forum
+		userForum, _ := forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// Check that the replied reply belongs to the same thread
+		var parentReplyID *uint
+		if req.ParentReplyID != "" {
+			parentIdInt, _ := strconv.Atoi(req.ParentReplyID)
+			parent, _ := repo.GetReplyByID(uint(parentIdInt))
+			if parent == nil || parent.ThreadID != thread.ID {
+				return fmt.Errorf(helper.ParentReplyNotInThread)
+			}
+
+			parentReplyID = &parent.ID
+		}
+
+		// Create the reply for the thread
+		repliedThread = thread
+		createdReply, err = repo.CreateReply(req, thread.ID, parentReplyID, user.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Attach the files uploaded for the reply
+		err = s.attachmentRepo.WithTx(tx).LinkAttachments(req.AttachmentIDs, thread.ForumID, user.UserID, nil, &createdReply.ID)
+		if err != nil {
+			return err
+		}
+
+		mentioned, err = s.saveMentions(tx, thread.ForumID, thread.ID, &createdReply.ID, createdReply.Text, user)
+		return err
+	})
 
-	// Get thread by id
-	thread, err := s.repository.GetThreadByID(reply.ThreadID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if user a member of the requested forum
-	userForum, _ := s.forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
-	if userForum == nil {
-		return nil, fmt.Errorf(helper.UserNotMember)
-	}
+	s.indexReply(createdReply)
+	s.bus.Publish(events.ReplyCreated{Actor: *user, Thread: *repliedThread, Reply: *createdReply})
+	s.publishMentions(user, repliedThread, createdReply, mentioned)
+
+	return createdReply, nil
+}
+
+func (s *threadService) VoteReply(req *request.ReqVoteReply, user *lib.UserData) (*models.ReplyVote, error) {
+	var replyVote *models.ReplyVote
+	var votedThread *models.Thread
+	var votedReply *models.Reply
+	var changed bool
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
+
+		// Get reply by id
+		replyIdInt, _ := strconv.Atoi(req.ReplyID)
+		reply, err := repo.GetReplyByID(uint(replyIdInt))
+		if err != nil {
+			return err
+		}
+
+		// Get thread by id
+		thread, err := repo.GetThreadByID(reply.ThreadID)
+		if err != nil {
+			return err
+		}
+
+		// Check if user a member of the requested forum
+		userForum, _ := forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// Update the reply data vote
+		votedThread, votedReply = thread, reply
+		replyVote, changed, err = repo.CreateOrUpdateReplyVote(reply, req, user.UserID)
+		return err
+	})
 
-	// Update the reply data vote
-	replyVote, err := s.repository.CreateOrUpdateReplyVote(reply, req, user.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.transactionRepo.CommitTransaction(tx); err != nil {
-		return nil, err
+	// Voting the same way again changes nothing and notifies nobody
+	if changed {
+		s.bus.Publish(events.ReplyVoted{Actor: *user, Thread: *votedThread, Reply: *votedReply, Vote: req.Vote})
 	}
 
 	return replyVote, nil
 }
 
-func (s *threadService) EditReply(req *request.ReqEditReply, user *lib.UserData) (*models.Reply, error) {
-	tx := s.transactionRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+func (s *threadService) RetractVoteReply(req *request.ReqRetractVoteReply, user *lib.UserData) error {
+	var votedThread *models.Thread
+	var votedReply *models.Reply
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
+
+		// Get reply by id
+		replyIdInt, _ := strconv.Atoi(req.ReplyID)
+		reply, err := repo.GetReplyByID(uint(replyIdInt))
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get reply by id
-	replyIdInt, _ := strconv.Atoi(req.ReplyID)
-	reply, err := s.repository.GetReplyByID(uint(replyIdInt))
+		// Get thread by id
+		thread, err := repo.GetThreadByID(reply.ThreadID)
+		if err != nil {
+			return err
+		}
+
+		// Check if user a member of the requested forum
+		userForum, _ := forumRepo.GetUserForumByID(thread.ForumID, user.UserID)
+		if userForum == nil {
+			return fmt.Errorf(helper.UserNotMember)
+		}
+
+		// Remove the user's vote from the reply
+		votedThread, votedReply = thread, reply
+		err = repo.DeleteReplyVote(reply, user.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf(helper.VoteNotFound)
+		}
+
+		return err
+	})
+
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Check if user created the reply
-	if reply.CreatedBy != user.UserID {
-		return nil, fmt.Errorf(helper.UserNotCreatedReply)
-	}
+	s.bus.Publish(events.ReplyVoteRetracted{Actor: *user, Thread: *votedThread, Reply: *votedReply})
+
+	return nil
+}
+
+func (s *threadService) EditReply(req *request.ReqEditReply, user *lib.UserData) (*models.Reply, error) {
+	var updatedReply *models.Reply
+	var repliedThread *models.Thread
+	var mentioned []uint
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get reply by id
+		replyIdInt, _ := strconv.Atoi(req.ReplyID)
+		reply, err := repo.GetReplyByID(uint(replyIdInt))
+		if err != nil {
+			return err
+		}
+
+		// Check if user created the reply
+		if reply.CreatedBy != user.UserID {
+			return fmt.Errorf(helper.UserNotCreatedReply)
+		}
+
+		// Get thread by id
+		repliedThread, err = repo.GetThreadByID(reply.ThreadID)
+		if err != nil {
+			return err
+		}
+
+		// Update the reply data
+		updatedReply, err = repo.UpdateReply(reply, req)
+		if err != nil {
+			return err
+		}
+
+		mentioned, err = s.saveMentions(tx, repliedThread.ForumID, repliedThread.ID, &updatedReply.ID, updatedReply.Text, user)
+		return err
+	})
 
-	// Update the reply data
-	updatedReply, err := s.repository.UpdateReply(reply, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.transactionRepo.CommitTransaction(tx); err != nil {
-		return nil, err
-	}
+	s.indexReply(updatedReply)
+	s.publishMentions(user, repliedThread, updatedReply, mentioned)
 
 	return updatedReply, nil
 }
@@ -291,66 +540,261 @@ func (s *threadService) GetThreadByID(threadID uint) (*models.Thread, error) {
 	return thread, nil
 }
 
-func (s *threadService) CheckModeratorForumFromThread(thread *models.Thread, user *lib.UserData) (bool, error) {
-	// Check if user a moderator of the requested forum
-	moderator, _ := s.forumRepo.GetModeratorByID(thread.ForumID, user.UserID)
-	if moderator == nil {
-		return false, fmt.Errorf(helper.UserNotModerator)
+func (s *threadService) DeleteThread(req *request.ReqDeleteThread, user *lib.UserData) error {
+	var deletedThread *models.Thread
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get thread by id
+		threadIdInt, _ := strconv.Atoi(req.ThreadID)
+		thread, err := repo.GetThreadByID(uint(threadIdInt))
+		if err != nil {
+			return err
+		}
+
+		if _, err := authorizeModeration(s.forumRepo.WithTx(tx), thread.ForumID, user, ActionDeleteThread); err != nil {
+			return err
+		}
+
+		// Delete the thread
+		deletedThread = thread
+		return repo.DeleteThread(thread)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	return true, nil
+	if err := s.searchIndex.RemoveThread(deletedThread.ID); err != nil {
+		lib.CommonLogger().Error(err)
+	}
+
+	return nil
 }
 
-func (s *threadService) DeleteThread(thread *models.Thread) error {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
+func (s *threadService) RestoreThread(req *request.ReqRestoreThread, user *lib.UserData) error {
+	var restoredThread *models.Thread
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+		forumRepo := s.forumRepo.WithTx(tx)
 
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+		// Get the deleted thread by id
+		threadIdInt, _ := strconv.Atoi(req.ThreadID)
+		thread, _ := repo.GetDeletedThreadByID(uint(threadIdInt))
+		if thread == nil {
+			return fmt.Errorf(helper.ThreadNotDeleted)
 		}
-	}()
 
-	// Delete the thread
-	err := s.repository.DeleteThread(thread)
+		// A thread of a deleted forum comes back with the forum
+		if _, err := forumRepo.GetForumById(thread.ForumID); err != nil {
+			return fmt.Errorf(helper.ForumNotFound)
+		}
+
+		if _, err := authorizeModeration(forumRepo, thread.ForumID, user, ActionDeleteThread); err != nil {
+			return err
+		}
+
+		// Bring back everything the deletion removed
+		restoredThread = thread
+		return repo.RestoreThread(thread)
+	})
+
+	if err != nil {
+		return err
+	}
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+	s.indexThread(restoredThread)
 
-	return err
+	replies, err := s.repository.ListThreadReplies(restoredThread.ID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return nil
+	}
+
+	for i := range replies {
+		s.indexReply(&replies[i])
+	}
+
+	return nil
 }
 
-func (s *threadService) GetThreadAndReplyByReplyID(replyID uint) (*models.Thread, *models.Reply, error) {
-	reply, err := s.repository.GetReplyByID(replyID)
+func (s *threadService) DeleteReply(req *request.ReqDeleteReply, user *lib.UserData) error {
+	var deletedIDs []uint
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get reply by id
+		replyIdInt, _ := strconv.Atoi(req.ReplyID)
+		reply, err := repo.GetReplyByID(uint(replyIdInt))
+		if err != nil {
+			return err
+		}
+
+		// Get thread by id
+		thread, err := repo.GetThreadByID(reply.ThreadID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := authorizeModeration(s.forumRepo.WithTx(tx), thread.ForumID, user, ActionDeleteReply); err != nil {
+			return err
+		}
+
+		// Delete the reply along with the replies under it
+		deletedIDs, err = repo.DeleteReply(reply)
+		return err
+	})
+
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
-	thread, err := s.repository.GetThreadByID(reply.ThreadID)
+	for _, id := range deletedIDs {
+		if err := s.searchIndex.RemoveReply(id); err != nil {
+			lib.CommonLogger().Error(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *threadService) RecountVotes() error {
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		// Recompute every vote counter from the vote tables
+		return s.repository.WithTx(tx).RecountVotes()
+	})
+}
+
+// saveMentions records the forum members a post mentions by @nim or @name
+// and returns the ones it did not mention before. Handles that don't match
+// exactly one member are dropped, so nobody outside the forum gets pinged.
+func (s *threadService) saveMentions(tx *gorm.DB, forumID uint, threadID uint, replyID *uint, text string, user *lib.UserData) ([]uint, error) {
+	handles := lib.ExtractMentions(text)
+	if len(handles) > maxMentionsPerPost {
+		handles = handles[:maxMentionsPerPost]
+	}
+
+	var nims, names []string
+	for _, handle := range handles {
+		if isNIM(handle) {
+			nims = append(nims, handle)
+		} else {
+			names = append(names, normalizeMentionName(handle))
+		}
+	}
+
+	mentionRepo := s.mentionRepo.WithTx(tx)
+
+	members, err := mentionRepo.FindMembersByHandles(forumID, nims, names)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return thread, reply, nil
+	// A name shared by several members is ambiguous and mentions nobody
+	byName := map[string][]uint{}
+	byNIM := map[string]uint{}
+	for _, member := range members {
+		if member.NIM != nil {
+			byNIM[*member.NIM] = member.ID
+		}
+		name := normalizeMentionName(member.Name)
+		byName[name] = append(byName[name], member.ID)
+	}
+
+	seen := map[uint]bool{}
+	var userIDs []uint
+	for _, handle := range handles {
+		var id uint
+		if isNIM(handle) {
+			id = byNIM[handle]
+		} else if ids := byName[normalizeMentionName(handle)]; len(ids) == 1 {
+			id = ids[0]
+		}
+
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	return mentionRepo.ReplaceMentions(threadID, replyID, user.UserID, userIDs)
 }
 
-func (s *threadService) DeleteReply(reply *models.Reply) error {
-	// Begin transaction
-	tx := s.transactionRepo.BeginTransaction()
+// publishMentions notifies the users newly mentioned by a thread, or by
+// reply when it is set
+func (s *threadService) publishMentions(user *lib.UserData, thread *models.Thread, reply *models.Reply, userIDs []uint) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	s.bus.Publish(events.UsersMentioned{Actor: *user, Thread: *thread, Reply: reply, UserIDs: userIDs})
+}
 
-	// Defer the rollback in case of an error
-	defer func() {
-		if r := recover(); r != nil {
-			s.transactionRepo.RollbackTransaction(tx)
+// isNIM tells whether a mention handle is a NIM rather than a name
+func isNIM(handle string) bool {
+	for _, c := range handle {
+		if c < '0' || c > '9' {
+			return false
 		}
-	}()
+	}
+
+	return handle != ""
+}
 
-	// Delete the reply
-	err := s.repository.DeleteReply(reply)
+// normalizeMentionName folds a name the same way as normalizedNameSQL
+func normalizeMentionName(name string) string {
+	return strings.NewReplacer(" ", "", ".", "", "_", "").Replace(strings.ToLower(name))
+}
 
-	// Commit the transaction
-	s.transactionRepo.CommitTransaction(tx)
+// indexThread hands a written thread to the search index. Failures are only
+// logged since the thread itself was saved and the index can be rebuilt.
+func (s *threadService) indexThread(thread *models.Thread) {
+	forum, err := s.forumRepo.GetForumById(thread.ForumID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	err = s.searchIndex.Index(&repository.SearchDocument{
+		ForumID:     forum.ID,
+		ForumName:   forum.ForumName,
+		ThreadID:    thread.ID,
+		ThreadTitle: thread.Title,
+		Text:        thread.Text,
+		CreatedAt:   thread.CreatedAt,
+	})
+	if err != nil {
+		lib.CommonLogger().Error(err)
+	}
+}
 
-	return err
+// indexReply hands a written reply to the search index, see indexThread
+func (s *threadService) indexReply(reply *models.Reply) {
+	thread, err := s.repository.GetThreadByID(reply.ThreadID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	forum, err := s.forumRepo.GetForumById(thread.ForumID)
+	if err != nil {
+		lib.CommonLogger().Error(err)
+		return
+	}
+
+	err = s.searchIndex.Index(&repository.SearchDocument{
+		ForumID:     forum.ID,
+		ForumName:   forum.ForumName,
+		ThreadID:    thread.ID,
+		ThreadTitle: thread.Title,
+		ReplyID:     reply.ID,
+		Text:        reply.Text,
+		CreatedAt:   reply.CreatedAt,
+	})
+	if err != nil {
+		lib.CommonLogger().Error(err)
+	}
 }
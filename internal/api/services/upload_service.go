@@ -0,0 +1,400 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/drdofx/talk-parmad/internal/api/constants"
+	"github.com/drdofx/talk-parmad/internal/api/helper"
+	"github.com/drdofx/talk-parmad/internal/api/lib"
+	"github.com/drdofx/talk-parmad/internal/api/models"
+	"github.com/drdofx/talk-parmad/internal/api/repository"
+	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+)
+
+const (
+	maxImageSize  = 5 << 20 // 5 MB
+	thumbnailSize = 256
+	// signedFileExpiry is how long the URLs files are redirected to stay
+	// valid, for stores that sign them
+	signedFileExpiry = 15 * time.Minute
+	// attachmentKeyPrefix holds the attachments, which are only served
+	// through OpenAttachment
+	attachmentKeyPrefix = "attachments/"
+)
+
+// FilesPath is the path uploaded files are served from. Stored image URLs
+// point there, so they stay valid whichever BlobStore holds the files.
+const FilesPath = constants.API_PATH + "/files/"
+
+type UploadService interface {
+	UploadForumImage(req *request.ReqUploadForumImage, user *lib.UserData) (*response.ResImage, error)
+	UploadProfileImage(req *request.ReqUploadProfileImage, user *lib.UserData) (*response.ResImage, error)
+	// UploadAttachment stores a file a thread or reply can then reference
+	// by the returned attachment's id
+	UploadAttachment(req *request.ReqUploadAttachment, user *lib.UserData) (*models.Attachment, error)
+	// OpenFile returns either a signed URL to redirect the client to or
+	// the file itself. Attachments are not served here.
+	OpenFile(req *request.ReqGetFile) (*lib.Blob, string, error)
+	// OpenAttachment does the same for an attachment the user may read
+	OpenAttachment(req *request.ReqGetAttachment, user *lib.UserData) (*lib.Blob, string, error)
+}
+
+type uploadService struct {
+	blobStore      lib.BlobStore
+	forumRepo      repository.ForumRepository
+	userRepo       repository.UserRepository
+	threadRepo     repository.ThreadRepository
+	attachmentRepo repository.AttachmentRepository
+}
+
+func NewUploadService(blobStore lib.BlobStore, forumRepo repository.ForumRepository, userRepo repository.UserRepository, threadRepo repository.ThreadRepository, attachmentRepo repository.AttachmentRepository) UploadService {
+	return &uploadService{blobStore, forumRepo, userRepo, threadRepo, attachmentRepo}
+}
+
+func (s *uploadService) UploadForumImage(req *request.ReqUploadForumImage, user *lib.UserData) (*response.ResImage, error) {
+	// Get the forum by id
+	forum, err := s.forumRepo.GetForumById(req.ForumID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := authorizeModeration(s.forumRepo, forum.ID, user, ActionChangeForumImage); err != nil {
+		return nil, err
+	}
+
+	res, err := s.storeImage(fmt.Sprintf("forums/%d", forum.ID), req.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	oldImage, oldThumbnail := forum.ForumImage, forum.ForumThumbnail
+
+	if err := s.forumRepo.UpdateForumImage(forum, res.Image, res.Thumbnail); err != nil {
+		s.deleteFiles(&res.Image, &res.Thumbnail)
+		return nil, err
+	}
+
+	s.deleteFiles(oldImage, oldThumbnail)
+
+	return res, nil
+}
+
+func (s *uploadService) UploadProfileImage(req *request.ReqUploadProfileImage, user *lib.UserData) (*response.ResImage, error) {
+	// Get the user data of the current user
+	me, err := s.userRepo.GetUserByID(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.storeImage(fmt.Sprintf("users/%d", me.ID), req.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	oldImage, oldThumbnail := me.ProfileImage, me.ProfileThumbnail
+
+	if err := s.userRepo.UpdateProfileImage(me, res.Image, res.Thumbnail); err != nil {
+		s.deleteFiles(&res.Image, &res.Thumbnail)
+		return nil, err
+	}
+
+	s.deleteFiles(oldImage, oldThumbnail)
+
+	return res, nil
+}
+
+func (s *uploadService) UploadAttachment(req *request.ReqUploadAttachment, user *lib.UserData) (*models.Attachment, error) {
+	// Get the forum by id
+	forum, err := s.forumRepo.GetForumById(req.ForumID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if user a member of the requested forum
+	userForum, _ := s.forumRepo.GetUserForumByID(forum.ID, user.UserID)
+	if userForum == nil {
+		return nil, fmt.Errorf(helper.UserNotMember)
+	}
+
+	maxSize := forum.AttachmentMaxSize
+	if maxSize <= 0 || maxSize > constants.AttachmentMaxSize {
+		maxSize = constants.AttachmentMaxSize
+	}
+
+	data, err := readUpload(req.File, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the sniffed type against the types the forum accepts
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !attachmentTypeAllowed(forum, contentType) {
+		return nil, fmt.Errorf(helper.FileTypeNotAllowed)
+	}
+
+	name, _, err := lib.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s%d/%s", attachmentKeyPrefix, forum.ID, name)
+	fileKey := prefix + constants.AttachmentExtensions[contentType]
+
+	if err := s.blobStore.Put(fileKey, contentType, data); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		ForumID:     forum.ID,
+		FileName:    attachmentFileName(req.File.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		URL:         FilesPath + fileKey,
+		CreatedBy:   user.UserID,
+	}
+
+	// Images get a thumbnail to show next to the post
+	if _, ok := lib.ImageExtensions[contentType]; ok {
+		thumbnailURL, err := s.storeThumbnail(prefix, data, contentType)
+		if err != nil {
+			s.deleteKey(fileKey)
+			return nil, err
+		}
+
+		attachment.ThumbnailURL = &thumbnailURL
+	}
+
+	if err := s.attachmentRepo.CreateAttachment(attachment); err != nil {
+		s.deleteFiles(&attachment.URL, attachment.ThumbnailURL)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *uploadService) OpenFile(req *request.ReqGetFile) (*lib.Blob, string, error) {
+	// Clean the key first so that ".." can't reach the attachments
+	key := strings.TrimPrefix(path.Clean("/"+req.Key), "/")
+
+	if strings.HasPrefix(key, attachmentKeyPrefix) {
+		return nil, "", fmt.Errorf(helper.FileNotFound)
+	}
+
+	return s.openKey(key)
+}
+
+func (s *uploadService) OpenAttachment(req *request.ReqGetAttachment, user *lib.UserData) (*lib.Blob, string, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentByID(req.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf(helper.FileNotFound)
+	}
+
+	if err := s.authorizeAttachmentRead(attachment, user); err != nil {
+		return nil, "", err
+	}
+
+	url := attachment.URL
+	if req.Thumbnail {
+		if attachment.ThumbnailURL == nil {
+			return nil, "", fmt.Errorf(helper.FileNotFound)
+		}
+
+		url = *attachment.ThumbnailURL
+	}
+
+	return s.openKey(strings.TrimPrefix(url, FilesPath))
+}
+
+// authorizeAttachmentRead checks that the user may read the thread or reply
+// the attachment belongs to. Attachments no post uses yet are only
+// available to the user who uploaded them.
+func (s *uploadService) authorizeAttachmentRead(attachment *models.Attachment, user *lib.UserData) error {
+	threadID := attachment.ThreadID
+
+	if attachment.ReplyID != nil {
+		// Deleted replies are not found
+		reply, err := s.threadRepo.GetReplyByID(*attachment.ReplyID)
+		if err != nil {
+			return fmt.Errorf(helper.FileNotFound)
+		}
+
+		threadID = &reply.ThreadID
+	}
+
+	if threadID == nil {
+		if attachment.CreatedBy != user.UserID {
+			return fmt.Errorf(helper.FileNotFound)
+		}
+
+		return nil
+	}
+
+	_, err := authorizeThreadRead(s.threadRepo, s.forumRepo, *threadID, user)
+	return err
+}
+
+// openKey returns a signed URL for the stored file when the store signs
+// them, or the file itself
+func (s *uploadService) openKey(key string) (*lib.Blob, string, error) {
+	signedURL, err := s.blobStore.SignedURL(key, signedFileExpiry)
+	if err != nil || signedURL != "" {
+		return nil, signedURL, err
+	}
+
+	blob, err := s.blobStore.Get(key)
+	if err != nil {
+		return nil, "", fmt.Errorf(helper.FileNotFound)
+	}
+
+	return blob, "", nil
+}
+
+// storeImage validates an uploaded image and stores it under prefix along
+// with its thumbnail
+func (s *uploadService) storeImage(prefix string, file *multipart.FileHeader) (*response.ResImage, error) {
+	data, err := readUpload(file, maxImageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trust the content rather than the file name or the declared type
+	contentType, ok := lib.DetectImageType(data)
+	if !ok {
+		return nil, fmt.Errorf(helper.FileTypeNotAllowed)
+	}
+
+	name, _, err := lib.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = prefix + "/" + name
+	imageKey := prefix + lib.ImageExtensions[contentType]
+
+	thumbnailURL, err := s.storeThumbnail(prefix, data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.blobStore.Put(imageKey, contentType, data); err != nil {
+		s.deleteFiles(&thumbnailURL)
+		return nil, err
+	}
+
+	return &response.ResImage{
+		Image:     FilesPath + imageKey,
+		Thumbnail: thumbnailURL,
+	}, nil
+}
+
+// storeThumbnail stores the thumbnail of an image next to the image's
+// key prefix and returns its URL
+func (s *uploadService) storeThumbnail(prefix string, data []byte, contentType string) (string, error) {
+	img, err := lib.DecodeImage(data)
+	if err != nil {
+		return "", fmt.Errorf(helper.InvalidImage)
+	}
+
+	thumbnail, thumbnailType, err := lib.EncodeThumbnail(lib.Thumbnail(img, thumbnailSize), contentType)
+	if err != nil {
+		return "", err
+	}
+
+	thumbnailKey := prefix + "_thumb" + lib.ImageExtensions[thumbnailType]
+	if err := s.blobStore.Put(thumbnailKey, thumbnailType, thumbnail); err != nil {
+		return "", err
+	}
+
+	return FilesPath + thumbnailKey, nil
+}
+
+func (s *uploadService) deleteFiles(urls ...*string) {
+	deleteStoredFiles(s.blobStore, urls...)
+}
+
+func (s *uploadService) deleteKey(key string) {
+	deleteStoredKey(s.blobStore, key)
+}
+
+// deleteStoredFiles removes the files behind stored URLs. URLs that don't
+// point to uploaded files are left alone.
+func deleteStoredFiles(blobStore lib.BlobStore, urls ...*string) {
+	for _, url := range urls {
+		if url != nil && strings.HasPrefix(*url, FilesPath) {
+			deleteStoredKey(blobStore, strings.TrimPrefix(*url, FilesPath))
+		}
+	}
+}
+
+// deleteStoredKey removes a stored file, logging failures since the file
+// is no longer referenced either way
+func deleteStoredKey(blobStore lib.BlobStore, key string) {
+	if err := blobStore.Delete(key); err != nil {
+		lib.CommonLogger().Error(err)
+	}
+}
+
+// readUpload reads an uploaded file, refusing files larger than maxSize
+// even when the declared size is wrong
+func readUpload(file *multipart.FileHeader, maxSize int64) ([]byte, error) {
+	if file.Size > maxSize {
+		return nil, fmt.Errorf(helper.FileTooLarge)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf(helper.FileTooLarge)
+	}
+
+	return data, nil
+}
+
+func attachmentTypeAllowed(forum *models.Forum, contentType string) bool {
+	types := forum.AttachmentTypes
+	if types == "" {
+		types = constants.DefaultAttachmentTypes
+	}
+
+	for _, t := range strings.Split(types, ",") {
+		if t == contentType {
+			_, ok := constants.AttachmentExtensions[t]
+			return ok
+		}
+	}
+
+	return false
+}
+
+// attachmentFileName keeps only the base name of an uploaded file, which
+// is shown to readers but never used as a path
+func attachmentFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "file"
+	}
+
+	// Column is varchar(255), which counts characters
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+
+	return name
+}
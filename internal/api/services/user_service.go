@@ -1,26 +1,69 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/drdofx/talk-parmad/internal/api/constants"
 	"github.com/drdofx/talk-parmad/internal/api/helper"
 	"github.com/drdofx/talk-parmad/internal/api/lib"
 	"github.com/drdofx/talk-parmad/internal/api/models"
 	"github.com/drdofx/talk-parmad/internal/api/repository"
 	"github.com/drdofx/talk-parmad/internal/api/request"
+	"github.com/drdofx/talk-parmad/internal/api/response"
+	"gorm.io/gorm"
 )
 
 type UserService interface {
 	CreateUser(req *request.ReqSaveUser) (*models.User, error)
-	LoginUser(req *request.ReqLoginUser) (interface{}, error)
+	LoginUser(req *request.ReqLoginUser) (*response.ResLoginUser, error)
+	RefreshToken(req *request.ReqRefreshToken) (*response.ResLoginUser, error)
+	LogoutUser(user *lib.UserData) error
+	CheckSession(user *lib.UserData) error
+	DetailMe(user *lib.UserData) (*response.ResUserProfile, error)
+	DetailUser(req *request.ReqDetailUser, viewer *lib.UserData) (*response.ResUserProfile, error)
+	UpdateProfile(req *request.ReqUpdateProfile, user *lib.UserData) (*response.ResUserProfile, error)
+	ChangePassword(req *request.ReqChangePassword, user *lib.UserData) error
+	ForgotPassword(req *request.ReqForgotPassword) error
+	ResetPassword(req *request.ReqResetPassword) error
+	SetUserStatus(req *request.ReqSetUserStatus, status string, admin *lib.UserData) error
 }
 
+// userStatusTTL is how long AuthorizeJWT trusts a cached user status before
+// reading it from the database again
+const userStatusTTL = 30 * time.Second
+
 type userService struct {
-	repository repository.UserRepository
+	repository      repository.UserRepository
+	sessionRepo     repository.SessionRepository
+	forumRepo       repository.ForumRepository
+	threadRepo      repository.ThreadRepository
+	transactionRepo repository.TransactionRepository
+	mailer          lib.Mailer
+	env             *lib.Env
+	statusCache     *lib.TTLCache[uint, string]
 }
 
-func NewUserService(repository repository.UserRepository) UserService {
-	return &userService{repository}
+func NewUserService(
+	repository repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	forumRepo repository.ForumRepository,
+	threadRepo repository.ThreadRepository,
+	transactionRepo repository.TransactionRepository,
+	mailer lib.Mailer,
+	env *lib.Env,
+) UserService {
+	return &userService{
+		repository,
+		sessionRepo,
+		forumRepo,
+		threadRepo,
+		transactionRepo,
+		mailer,
+		env,
+		lib.NewTTLCache[uint, string](userStatusTTL),
+	}
 }
 
 func (s *userService) CreateUser(req *request.ReqSaveUser) (*models.User, error) {
@@ -42,7 +85,7 @@ func (s *userService) CreateUser(req *request.ReqSaveUser) (*models.User, error)
 	return user, nil
 }
 
-func (s *userService) LoginUser(req *request.ReqLoginUser) (interface{}, error) {
+func (s *userService) LoginUser(req *request.ReqLoginUser) (*response.ResLoginUser, error) {
 	var user *models.User
 	var err error
 
@@ -61,10 +104,377 @@ func (s *userService) LoginUser(req *request.ReqLoginUser) (interface{}, error)
 		return nil, fmt.Errorf(helper.FailedLogin)
 	}
 
-	token := lib.GenerateJWT(user)
+	// Deactivated accounts are not allowed to sign in
+	if !isUserActive(user) {
+		return nil, fmt.Errorf(helper.UserInactive)
+	}
+
+	var res *response.ResLoginUser
+
+	err = s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		// Start a new session for the user
+		session, err := sessionRepo.CreateSession(user.ID)
+		if err != nil {
+			return err
+		}
+
+		res, err = s.issueTokens(sessionRepo, session, user)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *userService) RefreshToken(req *request.ReqRefreshToken) (*response.ResLoginUser, error) {
+	var res *response.ResLoginUser
+	reused := false
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		// Get the refresh token by its hash
+		refreshToken, err := sessionRepo.GetRefreshTokenByHash(lib.HashOpaqueToken(req.RefreshToken))
+		if err != nil {
+			return fmt.Errorf(helper.InvalidRefreshToken)
+		}
+
+		session, err := sessionRepo.GetSessionByID(refreshToken.SessionID)
+		if err != nil || session.RevokedAt != nil {
+			return fmt.Errorf(helper.InvalidRefreshToken)
+		}
+
+		// A refresh token that was already rotated is being used again, so
+		// it may have been stolen. Revoke the whole session to be safe.
+		if refreshToken.RevokedAt != nil {
+			reused = true
+			return sessionRepo.RevokeSession(session)
+		}
+
+		if refreshToken.ExpiresAt.Before(time.Now()) {
+			return fmt.Errorf(helper.InvalidRefreshToken)
+		}
+
+		user, err := s.repository.WithTx(tx).GetUserByID(session.UserID)
+		if err != nil {
+			return err
+		}
+
+		if !isUserActive(user) {
+			return fmt.Errorf(helper.UserInactive)
+		}
+
+		// Rotate the refresh token, invalidating the one that was used
+		if err := sessionRepo.RevokeRefreshToken(refreshToken); err != nil {
+			return err
+		}
+
+		res, err = s.issueTokens(sessionRepo, session, user)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, fmt.Errorf(helper.InvalidRefreshToken)
+	}
+
+	return res, nil
+}
+
+func (s *userService) LogoutUser(user *lib.UserData) error {
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		session, err := sessionRepo.GetSessionByID(user.SessionID)
+		if err != nil {
+			return err
+		}
+
+		// Revoke the session, which also revokes its refresh tokens
+		return sessionRepo.RevokeSession(session)
+	})
+}
+
+func (s *userService) CheckSession(user *lib.UserData) error {
+	session, err := s.sessionRepo.GetSessionByID(user.SessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf(helper.SessionRevoked)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != user.UserID || session.RevokedAt != nil {
+		return fmt.Errorf(helper.SessionRevoked)
+	}
+
+	// Re-check the account status so tokens of deactivated users stop
+	// working, using the cache to avoid a query on every request
+	status, ok := s.statusCache.Get(user.UserID)
+	if !ok {
+		u, err := s.repository.GetUserByID(user.UserID)
+		if err != nil {
+			return err
+		}
+
+		status = constants.UserStatusActive
+		if !isUserActive(u) {
+			status = constants.UserStatusInactive
+		}
+
+		s.statusCache.Set(user.UserID, status)
+	}
+
+	if status == constants.UserStatusInactive {
+		return fmt.Errorf(helper.UserInactive)
+	}
+
+	return nil
+}
+
+func (s *userService) DetailMe(user *lib.UserData) (*response.ResUserProfile, error) {
+	// Get the user data of the current user
+	me, err := s.repository.GetUserByID(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildProfile(me, me.ID)
+}
+
+func (s *userService) DetailUser(req *request.ReqDetailUser, viewer *lib.UserData) (*response.ResUserProfile, error) {
+	// Get the user data by id
+	user, err := s.repository.GetUserByID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.buildProfile(user, viewer.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hide the contact details on the public profile
+	profile.Email = ""
+	profile.NIM = nil
+
+	return profile, nil
+}
+
+func (s *userService) UpdateProfile(req *request.ReqUpdateProfile, user *lib.UserData) (*response.ResUserProfile, error) {
+	var updatedUser *models.User
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the user data of the current user
+		me, err := repo.GetUserByID(user.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Update the profile data
+		updatedUser, err = repo.Update(me, req)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildProfile(updatedUser, updatedUser.ID)
+}
+
+func (s *userService) ChangePassword(req *request.ReqChangePassword, user *lib.UserData) error {
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the user data of the current user
+		me, err := repo.GetUserByID(user.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Check the old password before replacing it
+		if !lib.ComparePassword(me.Password, req.OldPassword) {
+			return fmt.Errorf(helper.WrongOldPassword)
+		}
+
+		if err := lib.HashPassword(&req.NewPassword); err != nil {
+			return err
+		}
+
+		return repo.UpdatePassword(me, req.NewPassword)
+	})
+}
+
+func (s *userService) ForgotPassword(req *request.ReqForgotPassword) error {
+	// Do not tell the caller whether the email is registered
+	user, _ := s.repository.GetUserByEmail(req.Email)
+	if user == nil {
+		return nil
+	}
+
+	token, tokenHash, err := lib.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repository.CreatePasswordReset(user, tokenHash, lib.PasswordResetExpiry())
+	if err != nil {
+		return err
+	}
+
+	// Send the reset link to the user
+	return s.mailer.Send(&lib.Mail{
+		To:      user.Email,
+		Subject: "Reset your Talk Parmad password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to reset your password. The link can only be used once.\n\n%s?token=%s\n\nIf you did not ask for a password reset, you can ignore this email.",
+			user.Name, s.env.PasswordResetURL, token,
+		),
+	})
+}
+
+func (s *userService) ResetPassword(req *request.ReqResetPassword) error {
+	return s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the reset token by its hash, it must be unused and not expired
+		passwordReset, err := repo.GetPasswordResetByHash(lib.HashOpaqueToken(req.Token))
+		if err != nil || passwordReset.UsedAt != nil || passwordReset.ExpiresAt.Before(time.Now()) {
+			return fmt.Errorf(helper.InvalidResetToken)
+		}
+
+		user, err := repo.GetUserByID(passwordReset.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := lib.HashPassword(&req.NewPassword); err != nil {
+			return err
+		}
+
+		if err := repo.UpdatePassword(user, req.NewPassword); err != nil {
+			return err
+		}
+
+		if err := repo.UsePasswordReset(passwordReset); err != nil {
+			return err
+		}
+
+		// Sign the user out everywhere after the password was reset
+		return s.sessionRepo.WithTx(tx).RevokeUserSessions(user.ID)
+	})
+}
+
+func (s *userService) SetUserStatus(req *request.ReqSetUserStatus, status string, admin *lib.UserData) error {
+	if req.UserID == admin.UserID {
+		return fmt.Errorf(helper.CannotChangeSelf)
+	}
+
+	err := s.transactionRepo.WithTransaction(func(tx *gorm.DB) error {
+		repo := s.repository.WithTx(tx)
+
+		// Get the user data by id
+		user, err := repo.GetUserByID(req.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.UpdateStatus(user, status); err != nil {
+			return err
+		}
+
+		// Sign a deactivated user out of every session
+		if status == constants.UserStatusInactive {
+			return s.sessionRepo.WithTx(tx).RevokeUserSessions(user.ID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Drop the cached status so the change applies right away
+	s.statusCache.Delete(req.UserID)
+
+	return nil
+}
+
+// buildProfile collects the forums and post counts shown on a user profile.
+// Only the forums viewer may read are listed and counted.
+func (s *userService) buildProfile(user *models.User, viewerID uint) (*response.ResUserProfile, error) {
+	forums, err := s.forumRepo.ListMemberForums(user.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalThreads, err := s.threadRepo.CountUserThreads(user.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalReplies, err := s.threadRepo.CountUserReplies(user.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if forums == nil {
+		forums = []models.Forum{}
+	}
+
+	return &response.ResUserProfile{
+		ID:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		NIM:              user.NIM,
+		Role:             user.Role,
+		Prodi:            user.Prodi,
+		ProfileImage:     user.ProfileImage,
+		ProfileThumbnail: user.ProfileThumbnail,
+		CreatedAt:        user.CreatedAt,
+		Forums:           forums,
+		TotalThreads:     int(totalThreads),
+		TotalReplies:     int(totalReplies),
+	}, nil
+}
+
+// issueTokens creates a new refresh token for the session and signs an
+// access token bound to it
+func (s *userService) issueTokens(sessionRepo repository.SessionRepository, session *models.Session, user *models.User) (*response.ResLoginUser, error) {
+	refreshToken, refreshTokenHash, err := lib.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sessionRepo.CreateRefreshToken(session, refreshTokenHash, lib.RefreshTokenExpiry())
+	if err != nil {
+		return nil, err
+	}
+
+	token := lib.GenerateJWT(user, session.ID)
 	if token == "" {
 		return nil, fmt.Errorf(helper.FailedGenerateToken)
 	}
 
-	return token, nil
+	return &response.ResLoginUser{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func isUserActive(user *models.User) bool {
+	return user.Status == nil || *user.Status != constants.UserStatusInactive
 }